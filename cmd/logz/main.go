@@ -0,0 +1,194 @@
+// Package main provides a tool to analyze log files with logz.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bool64/logz"
+	"github.com/bool64/logz/logzpage"
+)
+
+func main() {
+	var (
+		listen      = flag.String("listen", "", "serve logz page at address, e.g. localhost:6060, instead of printing report")
+		format      = flag.String("format", formatAuto, "format of log lines: auto, json, logfmt or text")
+		top         = flag.Int("top", 20, "number of most frequent message families to report per level")
+		cardinality = flag.Uint("cardinality", 1000, "max number of message families per level")
+		samples     = flag.Uint("samples", 10, "max number of samples per message family")
+		filter      = flag.Bool("filter", false, "filter dynamic parts of messages")
+	)
+
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: logz [flags] [file ...]")
+		fmt.Fprintln(flag.CommandLine.Output(), "Reads log lines from files or stdin and groups them into message families.")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	a := newAnalyzer(logz.Config{
+		MaxCardinality: uint32(*cardinality),
+		MaxSamples:     uint32(*samples),
+		FilterMessage:  *filter,
+	}, *format)
+
+	files := flag.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	for _, fn := range files {
+		if err := a.readFile(fn); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *listen == "" {
+		a.report(os.Stdout, *top)
+
+		return
+	}
+
+	log.Printf("serving logz page at http://%s/", *listen)
+
+	srv := &http.Server{
+		Addr:              *listen,
+		Handler:           logzpage.Handler(a.observers...),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	log.Fatal(srv.ListenAndServe())
+}
+
+type analyzer struct {
+	format    string
+	observers []*logz.Observer
+	byLevel   map[string]*logz.Observer
+	last      time.Time
+	lines     int
+}
+
+func newAnalyzer(cfg logz.Config, format string) *analyzer {
+	a := analyzer{
+		format:  format,
+		byLevel: make(map[string]*logz.Observer, len(levels)),
+	}
+
+	for _, l := range levels {
+		cfg.Name = l
+		o := &logz.Observer{Config: cfg}
+
+		a.observers = append(a.observers, o)
+		a.byLevel[l] = o
+	}
+
+	return &a
+}
+
+func (a *analyzer) readFile(fn string) error {
+	if fn == "-" {
+		return a.read(os.Stdin)
+	}
+
+	f, err := os.Open(fn) //nolint:gosec // File is provided by user.
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Println(err)
+		}
+	}()
+
+	if err := a.read(f); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (a *analyzer) read(r io.Reader) error {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+
+	for s.Scan() {
+		a.observe(parseLine(s.Bytes(), a.format))
+	}
+
+	return s.Err()
+}
+
+func (a *analyzer) observe(r record) {
+	if r.msg == "" {
+		return
+	}
+
+	a.lines++
+
+	// Lines without timestamp inherit time of a previous line.
+	if r.time.IsZero() {
+		r.time = a.last
+		if r.time.IsZero() {
+			r.time = time.Now()
+		}
+	} else {
+		a.last = r.time
+	}
+
+	o, ok := a.byLevel[r.level]
+	if !ok {
+		o = a.byLevel[levelInfo]
+	}
+
+	o.ObserveMessageAt(r.time, r.msg, r.data)
+}
+
+func (a *analyzer) report(w io.Writer, top int) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Lines: %d\n", a.lines)
+
+	for _, o := range a.observers {
+		entries := o.GetEntries()
+		other := o.Other(false)
+
+		if len(entries) == 0 && other.Count == 0 {
+			continue
+		}
+
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].Count > entries[j].Count
+		})
+
+		fmt.Fprintf(tw, "\n%s: %d families\n", o.Name, len(entries))
+		fmt.Fprintln(tw, "COUNT\tFIRST\tLAST\tMESSAGE")
+
+		for i, e := range entries {
+			if i >= top {
+				fmt.Fprintf(tw, "...\t\t\t%d more\n", len(entries)-top)
+
+				break
+			}
+
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", e.Count, e.First.Format(time.RFC3339), e.Last.Format(time.RFC3339), e.Message)
+		}
+
+		if other.Count > 0 {
+			fmt.Fprintf(tw, "%d\t\t%s\t%s\n", other.Count, other.Last.Format(time.RFC3339), "Other Messages")
+		}
+	}
+
+	if err := tw.Flush(); err != nil {
+		log.Println(err)
+	}
+}
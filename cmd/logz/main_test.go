@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bool64/logz"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzer_report(t *testing.T) {
+	a := newAnalyzer(logz.Config{FilterMessage: true}, formatAuto)
+
+	require.NoError(t, a.read(strings.NewReader(`
+{"level":"error","ts":1700000000,"msg":"failed to connect to db1"}
+{"level":"error","ts":1700000010,"msg":"failed to connect to db2"}
+{"level":"info","ts":1700000020,"msg":"started"}
+plain line without time
+`)))
+
+	e := a.byLevel[levelError].Find("failed to connect to X")
+	assert.Equal(t, uint64(2), e.Count)
+	assert.Equal(t, int64(1700000000), e.First.Unix())
+	assert.Equal(t, int64(1700000010), e.Last.Unix())
+
+	assert.Equal(t, uint64(1), a.byLevel[levelInfo].Find("plain line without time").Count)
+
+	out := bytes.NewBuffer(nil)
+	a.report(out, 10)
+
+	assert.Contains(t, out.String(), "Lines: 4\n")
+	assert.Contains(t, out.String(), "Error: 1 families\n")
+	assert.Contains(t, out.String(), "failed to connect to X\n")
+}
+
+func TestAnalyzer_read_samples(t *testing.T) {
+	a := newAnalyzer(logz.Config{}, formatAuto)
+	lines := strings.Builder{}
+
+	for i := 0; i < 10; i++ {
+		lines.WriteString(`{"level":"error","msg":"early","id":` + fmt.Sprint(i) + "}\n")
+	}
+
+	for i := 0; i < 5000; i++ {
+		lines.WriteString(`{"level":"info","msg":"filler","payload":"` + strings.Repeat("x", i%100) + `"}` + "\n")
+	}
+
+	require.NoError(t, a.read(strings.NewReader(lines.String())))
+
+	e := a.byLevel[levelError].Find("early")
+	require.NotEmpty(t, e.Samples)
+
+	for _, s := range e.Samples {
+		j, err := json.Marshal(s.Data)
+		require.NoError(t, err)
+		assert.Contains(t, string(j), `"msg":"early"`)
+	}
+}
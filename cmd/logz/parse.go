@@ -0,0 +1,358 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Supported formats of log lines.
+const (
+	formatAuto   = "auto"
+	formatJSON   = "json"
+	formatLogfmt = "logfmt"
+	formatText   = "text"
+)
+
+// Level names of observers.
+const (
+	levelDebug   = "Debug"
+	levelInfo    = "Info"
+	levelWarning = "Warning"
+	levelError   = "Error"
+	levelFatal   = "Fatal"
+)
+
+var levels = []string{levelDebug, levelInfo, levelWarning, levelError, levelFatal}
+
+// record is a parsed log line.
+type record struct {
+	time  time.Time
+	level string
+	msg   string
+	data  interface{}
+}
+
+var (
+	msgKeys   = []string{"msg", "message", "@message"}
+	levelKeys = []string{"level", "lvl", "severity", "@level"}
+	timeKeys  = []string{"time", "ts", "timestamp", "@timestamp", "t"}
+)
+
+// Range of timestamps that can be observed.
+var (
+	minTime = time.Unix(0, 0)
+	maxTime = time.Unix(0, math.MaxInt64)
+)
+
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.000Z0700",
+	"2006-01-02 15:04:05.000000",
+	"2006-01-02 15:04:05.000",
+	"2006-01-02 15:04:05",
+	"2006/01/02 15:04:05.000000",
+	"2006/01/02 15:04:05",
+}
+
+// parseLine parses log line of a format, empty record message means line was not recognized.
+func parseLine(line []byte, format string) record {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return record{}
+	}
+
+	if format == formatAuto {
+		format = detectFormat(line)
+	}
+
+	switch format {
+	case formatJSON:
+		if r, ok := parseJSON(line); ok {
+			return r
+		}
+	case formatLogfmt:
+		if r, ok := parseLogfmt(line); ok {
+			return r
+		}
+	}
+
+	return parseText(line)
+}
+
+func detectFormat(line []byte) string {
+	if line[0] == '{' {
+		return formatJSON
+	}
+
+	if bytes.Contains(line, []byte("msg=")) || bytes.Contains(line, []byte("level=")) {
+		return formatLogfmt
+	}
+
+	return formatText
+}
+
+func parseJSON(line []byte) (record, bool) {
+	var fields map[string]interface{}
+
+	if err := json.Unmarshal(line, &fields); err != nil {
+		return record{}, false
+	}
+
+	r := record{
+		// Line is copied, because scanner reuses its buffer for next lines.
+		data: json.RawMessage(append([]byte(nil), line...)),
+	}
+
+	for _, k := range msgKeys {
+		if v, ok := fields[k].(string); ok {
+			r.msg = v
+
+			break
+		}
+	}
+
+	for _, k := range levelKeys {
+		if v, ok := fields[k].(string); ok {
+			r.level = normalizeLevel(v)
+
+			break
+		}
+	}
+
+	for _, k := range timeKeys {
+		switch v := fields[k].(type) {
+		case string:
+			r.time = parseTime(v)
+		case float64:
+			r.time = epochTime(v)
+		}
+
+		if !r.time.IsZero() {
+			break
+		}
+	}
+
+	return r, r.msg != ""
+}
+
+func parseLogfmt(line []byte) (record, bool) {
+	fields := logfmtFields(string(line))
+	if len(fields) == 0 {
+		return record{}, false
+	}
+
+	r := record{}
+	data := make(map[string]string, len(fields))
+
+	for _, f := range fields {
+		data[f[0]] = f[1]
+	}
+
+	r.data = data
+
+	for _, k := range msgKeys {
+		if v, ok := data[k]; ok {
+			r.msg = v
+
+			break
+		}
+	}
+
+	for _, k := range levelKeys {
+		if v, ok := data[k]; ok {
+			r.level = normalizeLevel(v)
+
+			break
+		}
+	}
+
+	for _, k := range timeKeys {
+		if v, ok := data[k]; ok {
+			if r.time = parseTime(v); !r.time.IsZero() {
+				break
+			}
+		}
+	}
+
+	return r, r.msg != ""
+}
+
+// logfmtFields splits line into key and value pairs, values may be double-quoted.
+func logfmtFields(line string) [][2]string {
+	var res [][2]string
+
+	for {
+		line = strings.TrimLeft(line, " \t")
+		if line == "" {
+			return res
+		}
+
+		eq := strings.IndexAny(line, "= \t")
+		if eq <= 0 || line[eq] != '=' {
+			// Bare word without value.
+			end := strings.IndexAny(line, " \t")
+			if end < 0 {
+				return res
+			}
+
+			line = line[end:]
+
+			continue
+		}
+
+		key := line[:eq]
+		line = line[eq+1:]
+
+		var val string
+
+		if strings.HasPrefix(line, `"`) {
+			end := closingQuote(line)
+			if v, err := strconv.Unquote(line[:end]); err == nil {
+				val = v
+			} else {
+				val = strings.Trim(line[:end], `"`)
+			}
+
+			line = line[end:]
+		} else {
+			end := strings.IndexAny(line, " \t")
+			if end < 0 {
+				end = len(line)
+			}
+
+			val = line[:end]
+			line = line[end:]
+		}
+
+		res = append(res, [2]string{key, val})
+	}
+}
+
+// closingQuote returns position after closing quote of a quoted string at the start of s.
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+
+	return len(s)
+}
+
+// parseText takes optional leading timestamp and level from a plain text line, the rest is a message.
+func parseText(line []byte) record {
+	s := string(line)
+	r := record{data: s}
+
+	r.time, s = cutTime(s)
+
+	word := s
+	if i := strings.IndexAny(s, " \t"); i > 0 {
+		word = s[:i]
+	}
+
+	if l := normalizeLevel(strings.Trim(word, "[]:")); l != "" {
+		r.level = l
+		s = strings.TrimLeft(s[len(word):], " \t:")
+	}
+
+	r.msg = s
+
+	return r
+}
+
+// cutTime removes leading timestamp from a line, timestamp may contain a space between date and time.
+//
+// Timestamp out of observable range is removed, but zero time is returned.
+func cutTime(s string) (time.Time, string) {
+	first, rest, _ := strings.Cut(s, " ")
+	if t := parseLayouts(first); !t.IsZero() {
+		return validTime(t), strings.TrimLeft(rest, " \t")
+	}
+
+	second, rest, _ := strings.Cut(rest, " ")
+	if t := parseLayouts(first + " " + second); !t.IsZero() {
+		return validTime(t), strings.TrimLeft(rest, " \t")
+	}
+
+	return time.Time{}, s
+}
+
+func parseLayouts(s string) time.Time {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// parseTime parses formatted timestamp or unix epoch, zero time is returned for invalid or out of range timestamp.
+func parseTime(s string) time.Time {
+	if t := parseLayouts(s); !t.IsZero() {
+		return validTime(t)
+	}
+
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return epochTime(f)
+	}
+
+	return time.Time{}
+}
+
+// epochTime converts unix timestamp in seconds, milliseconds or nanoseconds to time.
+//
+// Zero time is returned for negative or too large values.
+func epochTime(f float64) time.Time {
+	// Negated comparison also rejects NaN.
+	if !(f >= 0 && f < math.MaxInt64) {
+		return time.Time{}
+	}
+
+	switch {
+	case f > 1e17:
+		return time.Unix(0, int64(f))
+	case f > 1e11:
+		return time.UnixMilli(int64(f))
+	default:
+		sec := int64(f)
+
+		return time.Unix(sec, int64((f-float64(sec))*1e9))
+	}
+}
+
+// validTime returns zero time if t can not be observed: it is before Unix epoch
+// or out of range of int64 nanoseconds.
+func validTime(t time.Time) time.Time {
+	if t.Before(minTime) || t.After(maxTime) {
+		return time.Time{}
+	}
+
+	return t
+}
+
+// normalizeLevel maps level name to one of observer levels, empty result means unknown level.
+func normalizeLevel(l string) string {
+	switch strings.ToLower(l) {
+	case "trace", "debug", "dbg":
+		return levelDebug
+	case "info", "inf", "notice", "important":
+		return levelInfo
+	case "warn", "warning", "wrn":
+		return levelWarning
+	case "error", "err", "eror":
+		return levelError
+	case "dpanic", "panic", "fatal", "crit", "critical", "alert", "emerg":
+		return levelFatal
+	}
+
+	return ""
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLine(t *testing.T) {
+	for _, tc := range []struct {
+		line  string
+		time  string
+		level string
+		msg   string
+	}{
+		{
+			line:  `{"level":"warn","ts":1700000000.5,"caller":"app/main.go:10","msg":"slow query","duration":1.5}`,
+			time:  "2023-11-14T22:13:20.5Z",
+			level: levelWarning,
+			msg:   "slow query",
+		},
+		{
+			line:  `{"level":"error","time":"2023-11-14T22:13:20.123Z","msg":"request failed","error":"timeout"}`,
+			time:  "2023-11-14T22:13:20.123Z",
+			level: levelError,
+			msg:   "request failed",
+		},
+		{
+			line:  `time=2023-11-14T22:13:20Z level=INFO msg="user \"foo\" logged in" user=foo`,
+			time:  "2023-11-14T22:13:20Z",
+			level: levelInfo,
+			msg:   `user "foo" logged in`,
+		},
+		{
+			line:  `2023/11/14 22:13:20 [warn] disk is almost full`,
+			time:  "2023-11-14T22:13:20Z",
+			level: levelWarning,
+			msg:   "disk is almost full",
+		},
+		{
+			line:  `2023-11-14 22:13:20.123 ERROR: connection reset`,
+			time:  "2023-11-14T22:13:20.123Z",
+			level: levelError,
+			msg:   "connection reset",
+		},
+		{
+			line:  `{"level":"error","ts":-1.5,"msg":"negative epoch"}`,
+			level: levelError,
+			msg:   "negative epoch",
+		},
+		{
+			line:  `1969-12-31 23:59:30 ERROR before epoch`,
+			level: levelError,
+			msg:   "before epoch",
+		},
+		{
+			line: `time=2300-01-01T00:00:00Z msg="after nanoseconds range"`,
+			msg:  "after nanoseconds range",
+		},
+		{
+			line: `just a message`,
+			msg:  "just a message",
+		},
+	} {
+		t.Run(tc.msg, func(t *testing.T) {
+			r := parseLine([]byte(tc.line), formatAuto)
+
+			assert.Equal(t, tc.msg, r.msg)
+			assert.Equal(t, tc.level, r.level)
+
+			if tc.time == "" {
+				assert.True(t, r.time.IsZero())
+			} else {
+				assert.Equal(t, tc.time, r.time.UTC().Format(time.RFC3339Nano))
+			}
+		})
+	}
+}
+
+func TestParseLine_jsonData(t *testing.T) {
+	r := parseLine([]byte(`{"msg":"hello","k":"v"}`), formatJSON)
+
+	assert.Equal(t, json.RawMessage(`{"msg":"hello","k":"v"}`), r.data)
+}
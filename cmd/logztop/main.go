@@ -0,0 +1,67 @@
+// Package main provides a terminal viewer for logz pages of remote processes.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+func main() {
+	t := top{}
+
+	var interval time.Duration
+
+	flag.DurationVar(&interval, "interval", 2*time.Second, "refresh interval")
+	flag.StringVar(&t.level, "level", "", "initial level, first level of endpoint by default")
+	flag.StringVar(&t.query, "filter", "", "initial message filter")
+	flag.IntVar(&t.limit, "n", 30, "max number of message families to show")
+
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: logztop [flags] URL [URL ...]")
+		fmt.Fprintln(flag.CommandLine.Output(), "Shows message families from logz pages, e.g. http://localhost:6060/debug/logz.")
+		fmt.Fprintln(flag.CommandLine.Output(), "Families are merged from all endpoints and ordered by recent rate.")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	t.endpoints = flag.Args()
+	if len(t.endpoints) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	t.client = &http.Client{Timeout: interval}
+
+	commands := make(chan string)
+
+	go func() {
+		s := bufio.NewScanner(os.Stdin)
+		for s.Scan() {
+			commands <- s.Text()
+		}
+
+		close(commands)
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		t.poll(context.Background())
+		t.render(os.Stdout)
+
+		select {
+		case <-ticker.C:
+		case cmd, ok := <-commands:
+			if !ok || !t.command(cmd) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bool64/logz"
+)
+
+// page is a JSON representation of logz page.
+type page struct {
+	Level   string       `json:"level"`
+	Levels  []string     `json:"levels"`
+	Entries []logz.Entry `json:"entries"`
+	Details logz.Entry   `json:"details"`
+	Other   logz.Entry   `json:"other"`
+}
+
+// row is a message family merged from all endpoints.
+type row struct {
+	logz.Entry
+
+	// Rate is a number of events per second since previous poll.
+	Rate float64
+}
+
+type top struct {
+	client    *http.Client
+	endpoints []string
+
+	level   string
+	query   string
+	details string
+	limit   int
+
+	levels []string
+	rows   []row
+	other  logz.Entry
+	errors []string
+
+	prevCounts map[string]uint64
+	prevTime   time.Time
+}
+
+// fetch requests page data from endpoint.
+func (t *top) fetch(ctx context.Context, endpoint string) (page, error) {
+	var p page
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return p, err
+	}
+
+	q := u.Query()
+	q.Set("format", "json")
+
+	if t.level != "" {
+		q.Set("level", t.level)
+	}
+
+	if t.query != "" {
+		q.Set("q", t.query)
+	}
+
+	if t.details != "" {
+		q.Set("msg", t.details)
+	}
+
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return p, err
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return p, err
+	}
+
+	defer func() {
+		_ = resp.Body.Close() //nolint:errcheck // Nothing to do with error.
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return p, fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&p)
+
+	return p, err
+}
+
+// poll fetches all endpoints and merges their entries.
+func (t *top) poll(ctx context.Context) {
+	now := time.Now()
+	merged := map[string]*row{}
+	t.errors = t.errors[:0]
+	t.other = logz.Entry{}
+
+	var details []logz.Sample
+
+	for _, endpoint := range t.endpoints {
+		p, err := t.fetch(ctx, endpoint)
+		if err != nil {
+			t.errors = append(t.errors, endpoint+": "+err.Error())
+
+			continue
+		}
+
+		if len(p.Levels) > len(t.levels) {
+			t.levels = p.Levels
+		}
+
+		if t.level == "" {
+			t.level = p.Level
+		}
+
+		// Endpoint falls back to its first level if requested one is missing.
+		if p.Level != t.level {
+			continue
+		}
+
+		for _, e := range p.Entries {
+			r, ok := merged[e.Message]
+			if !ok {
+				merged[e.Message] = &row{Entry: e}
+
+				continue
+			}
+
+			mergeEntry(&r.Entry, e)
+		}
+
+		mergeEntry(&t.other, p.Other)
+
+		details = append(details, p.Details.Samples...)
+	}
+
+	t.rows = t.rows[:0]
+	counts := make(map[string]uint64, len(merged))
+
+	for msg, r := range merged {
+		counts[msg] = r.Count
+
+		if prev, ok := t.prevCounts[msg]; ok && r.Count >= prev && !t.prevTime.IsZero() {
+			r.Rate = float64(r.Count-prev) / now.Sub(t.prevTime).Seconds()
+		}
+
+		if msg == t.details {
+			r.Samples = details
+		}
+
+		t.rows = append(t.rows, *r)
+	}
+
+	sort.Slice(t.rows, func(i, j int) bool {
+		ri, rj := t.rows[i], t.rows[j]
+
+		if ri.Rate != rj.Rate {
+			return ri.Rate > rj.Rate
+		}
+
+		if ri.CountLastMinute != rj.CountLastMinute {
+			return ri.CountLastMinute > rj.CountLastMinute
+		}
+
+		return ri.Last.After(rj.Last)
+	})
+
+	t.prevCounts = counts
+	t.prevTime = now
+}
+
+func mergeEntry(dst *logz.Entry, e logz.Entry) {
+	if dst.Count == 0 {
+		*dst = e
+
+		return
+	}
+
+	dst.Count += e.Count
+	dst.CountLastMinute += e.CountLastMinute
+	dst.CountLast5Minutes += e.CountLast5Minutes
+	dst.CountLastHour += e.CountLastHour
+
+	if e.First.Before(dst.First) {
+		dst.First = e.First
+	}
+
+	if e.Last.After(dst.Last) {
+		dst.Last = e.Last
+	}
+}
+
+// render writes current state to terminal.
+func (t *top) render(w io.Writer) {
+	// Move cursor home and clear screen.
+	fmt.Fprint(w, "\033[H\033[2J")
+
+	levels := make([]string, 0, len(t.levels))
+
+	for _, l := range t.levels {
+		if l == t.level {
+			l = "[" + l + "]"
+		}
+
+		levels = append(levels, l)
+	}
+
+	fmt.Fprintf(w, "logztop %s  %s\n", time.Now().Format("15:04:05"), strings.Join(t.endpoints, " "))
+	fmt.Fprintf(w, "levels: %s  filter: %q\n", strings.Join(levels, " "), t.query)
+
+	for _, e := range t.errors {
+		fmt.Fprintln(w, "error:", e)
+	}
+
+	fmt.Fprintln(w)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprintln(tw, "#\tRATE/S\t1M\t5M\t1H\tCOUNT\tLAST\t MESSAGE")
+
+	var selected *row
+
+	for i, r := range t.rows {
+		if i >= t.limit {
+			break
+		}
+
+		if r.Message == t.details {
+			selected = &t.rows[i]
+		}
+
+		fmt.Fprintf(tw, "%d\t%.2f\t%d\t%d\t%d\t%d\t%s\t %s\n", i+1, r.Rate,
+			r.CountLastMinute, r.CountLast5Minutes, r.CountLastHour, r.Count,
+			r.Last.Format("15:04:05"), r.Message)
+	}
+
+	if t.other.Count > 0 {
+		fmt.Fprintf(tw, "\t\t%d\t%d\t%d\t%d\t%s\t %s\n",
+			t.other.CountLastMinute, t.other.CountLast5Minutes, t.other.CountLastHour, t.other.Count,
+			t.other.Last.Format("15:04:05"), "Other Messages")
+	}
+
+	_ = tw.Flush() //nolint:errcheck // Terminal output.
+
+	if selected != nil {
+		fmt.Fprintf(w, "\n%s\n", selected.Message)
+
+		sort.Slice(selected.Samples, func(i, j int) bool {
+			return selected.Samples[i].Time.After(selected.Samples[j].Time)
+		})
+
+		for _, s := range selected.Samples {
+			data, err := json.Marshal(s.Data)
+			if err != nil {
+				data = []byte(err.Error())
+			}
+
+			fmt.Fprintf(w, "%s %s\n", s.Time.Format(time.RFC3339), strings.TrimSpace(string(data)))
+		}
+	}
+
+	fmt.Fprint(w, "\ncommands: l [level] - next or given level, /text - filter, d N - details of row N, d - close details, q - quit\n> ")
+}
+
+// command applies user input, false result means exit.
+func (t *top) command(cmd string) bool {
+	cmd = strings.TrimSpace(cmd)
+
+	switch {
+	case cmd == "q":
+		return false
+	case cmd == "l":
+		t.nextLevel()
+	case strings.HasPrefix(cmd, "l "):
+		t.setLevel(strings.TrimSpace(cmd[2:]))
+	case strings.HasPrefix(cmd, "/"):
+		t.query = cmd[1:]
+	case cmd == "d":
+		t.details = ""
+	case strings.HasPrefix(cmd, "d "):
+		var n int
+
+		if _, err := fmt.Sscan(cmd[2:], &n); err == nil && n > 0 && n <= len(t.rows) {
+			t.details = t.rows[n-1].Message
+		}
+	}
+
+	return true
+}
+
+func (t *top) nextLevel() {
+	for i, l := range t.levels {
+		if l == t.level {
+			t.setLevel(t.levels[(i+1)%len(t.levels)])
+
+			return
+		}
+	}
+
+	if len(t.levels) > 0 {
+		t.setLevel(t.levels[0])
+	}
+}
+
+// setLevel switches level and resets state of previous level.
+func (t *top) setLevel(level string) {
+	t.level = level
+	t.details = ""
+	t.prevCounts = nil
+	t.prevTime = time.Time{}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bool64/logz"
+	"github.com/bool64/logz/logzpage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTop_poll(t *testing.T) {
+	warn1 := &logz.Observer{Config: logz.Config{Name: "Warning"}}
+	err1 := &logz.Observer{Config: logz.Config{Name: "Error"}}
+	err2 := &logz.Observer{Config: logz.Config{Name: "Error"}}
+
+	srv1 := httptest.NewServer(logzpage.Handler(warn1, err1))
+	defer srv1.Close()
+
+	srv2 := httptest.NewServer(logzpage.Handler(err2))
+	defer srv2.Close()
+
+	warn1.ObserveMessage("careful", nil)
+	err1.ObserveMessage("failed", 1)
+	err2.ObserveMessage("failed", 2)
+	err2.ObserveMessage("broken", 3)
+
+	tp := top{
+		client:    http.DefaultClient,
+		endpoints: []string{srv1.URL, srv2.URL},
+		limit:     10,
+	}
+
+	tp.poll(context.Background())
+	assert.Equal(t, "Warning", tp.level)
+	assert.Equal(t, []string{"Warning", "Error"}, tp.levels)
+	require.Len(t, tp.rows, 1)
+	assert.Equal(t, "careful", tp.rows[0].Message)
+
+	assert.True(t, tp.command("l"))
+	tp.poll(context.Background())
+	assert.Equal(t, "Error", tp.level)
+	require.Len(t, tp.rows, 2)
+
+	err1.ObserveMessage("failed", 4)
+	tp.poll(context.Background())
+	require.Len(t, tp.rows, 2)
+	assert.Equal(t, "failed", tp.rows[0].Message)
+	assert.Equal(t, uint64(3), tp.rows[0].Count)
+	assert.Greater(t, tp.rows[0].Rate, 0.0)
+
+	assert.True(t, tp.command("d 1"))
+	tp.poll(context.Background())
+	assert.Len(t, tp.rows[0].Samples, 3)
+
+	out := bytes.NewBuffer(nil)
+	tp.render(out)
+	assert.Contains(t, out.String(), "levels: Warning [Error]")
+	assert.Contains(t, out.String(), " failed\n")
+
+	assert.True(t, tp.command("/brok"))
+	tp.poll(context.Background())
+	require.Len(t, tp.rows, 1)
+	assert.Equal(t, "broken", tp.rows[0].Message)
+
+	assert.False(t, tp.command("q"))
+}
@@ -0,0 +1,167 @@
+package ctxz
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+
+	"github.com/vearutop/lograte/filter"
+)
+
+// maxCauseLen limits length of filtered root cause message.
+const maxCauseLen = 100
+
+// maxCauseDepth limits number of errors visited in a chain, it protects from cyclic Unwrap.
+const maxCauseDepth = 100
+
+// sentinels are well-known errors that are recognized anywhere in error chain.
+var sentinels = []struct {
+	err  error
+	name string
+}{
+	{err: context.Canceled, name: "context.Canceled"},
+	{err: context.DeadlineExceeded, name: "context.DeadlineExceeded"},
+	{err: io.EOF, name: "io.EOF"},
+	{err: io.ErrUnexpectedEOF, name: "io.ErrUnexpectedEOF"},
+	{err: os.ErrNotExist, name: "os.ErrNotExist"},
+	{err: os.ErrPermission, name: "os.ErrPermission"},
+	{err: os.ErrDeadlineExceeded, name: "os.ErrDeadlineExceeded"},
+}
+
+// errorCause describes root cause of an error chain.
+type errorCause struct {
+	// sentinel is a matched well-known error.
+	sentinel error
+
+	Type     string `json:"type"`
+	Sentinel string `json:"sentinel,omitempty"`
+	Message  string `json:"message"`
+
+	// wrapped is true if root cause differs from original error.
+	wrapped bool
+}
+
+// name identifies cause for grouping.
+func (c errorCause) name() string {
+	if c.Sentinel != "" {
+		return c.Sentinel
+	}
+
+	// Errors created with errors.New or fmt.Errorf without wrapping have no distinctive type,
+	// so message is used with dynamic parts like IDs filtered out to keep cardinality low.
+	if c.Type == "*errors.errorString" {
+		return fmt.Sprintf("%q", filter.Dynamic([]byte(c.Message), maxCauseLen))
+	}
+
+	return c.Type
+}
+
+// rootCause unwraps error chain, at most maxCauseDepth errors are visited.
+//
+// In joined errors, the first branch that contains matched sentinel is followed,
+// so that Type and Sentinel describe the same error, first branch is followed if there is no sentinel.
+func rootCause(err error) errorCause {
+	c := errorCause{}
+
+	for _, s := range sentinels {
+		if is(err, s.err) {
+			c.Sentinel = s.name
+			c.sentinel = s.err
+
+			break
+		}
+	}
+
+	for depth := 0; depth < maxCauseDepth; depth++ {
+		var next error
+
+		switch e := err.(type) { //nolint:errorlint // Chain is unwrapped explicitly.
+		case interface{ Unwrap() error }:
+			next = e.Unwrap()
+		case interface{ Unwrap() []error }:
+			next = c.branch(e.Unwrap())
+		}
+
+		if next == nil {
+			break
+		}
+
+		err = next
+		c.wrapped = true
+	}
+
+	c.Type = fmt.Sprintf("%T", err)
+	c.Message = err.Error()
+
+	return c
+}
+
+// branch selects joined error to follow.
+func (c errorCause) branch(errs []error) error {
+	if c.sentinel != nil {
+		for _, e := range errs {
+			if is(e, c.sentinel) {
+				return e
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs[0]
+	}
+
+	return nil
+}
+
+// is works like errors.Is, but visits at most maxCauseDepth errors.
+func is(err, target error) bool {
+	budget := maxCauseDepth
+
+	return isBudget(err, target, &budget)
+}
+
+func isBudget(err, target error, budget *int) bool {
+	comparable := reflect.TypeOf(target).Comparable()
+
+	for err != nil && *budget > 0 {
+		*budget--
+
+		if comparable && err == target {
+			return true
+		}
+
+		if x, ok := err.(interface{ Is(error) bool }); ok && x.Is(target) { //nolint:errorlint // Chain is unwrapped explicitly.
+			return true
+		}
+
+		switch e := err.(type) { //nolint:errorlint // Chain is unwrapped explicitly.
+		case interface{ Unwrap() error }:
+			err = e.Unwrap()
+		case interface{ Unwrap() []error }:
+			for _, b := range e.Unwrap() {
+				if isBudget(b, target, budget) {
+					return true
+				}
+			}
+
+			return false
+		default:
+			return false
+		}
+	}
+
+	return false
+}
+
+// causeFamily returns message family with root cause of the first error value.
+func causeFamily(msg string, keysAndValues []interface{}) string {
+	for i := 1; i < len(keysAndValues); i += 2 {
+		if err, ok := keysAndValues[i].(error); ok && err != nil {
+			return msg + " [" + rootCause(err).name() + "]"
+		}
+	}
+
+	return msg
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/bool64/ctxd"
 	"github.com/bool64/logz"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Observer keeps track of logged messages.
@@ -21,11 +22,52 @@ type Observer struct {
 	warn      *logz.Observer
 	error     *logz.Observer
 	logger    ctxd.Logger
+	repeated  *repeated
+
+	errorCauses bool
+}
+
+// repeated writes summaries of suppressed messages by level.
+type repeated struct {
+	debug     func(msg string, count uint64)
+	info      func(msg string, count uint64)
+	important func(msg string, count uint64)
+	warn      func(msg string, count uint64)
+	error     func(msg string, count uint64)
+}
+
+func newRepeated(l ctxd.Logger) *repeated {
+	summary := func(log func(ctx context.Context, msg string, keysAndValues ...interface{})) func(msg string, count uint64) {
+		return func(msg string, count uint64) {
+			log(context.Background(), fmt.Sprintf("message repeated %d times: %s", count, msg), "repeated", count)
+		}
+	}
+
+	return &repeated{
+		debug:     summary(l.Debug),
+		info:      summary(l.Info),
+		important: summary(l.Important),
+		warn:      summary(l.Warn),
+		error:     summary(l.Error),
+	}
 }
 
 type tuples struct {
 	ctx context.Context
 	kv  []interface{}
+
+	// errorCauses enables recording of root causes of error values.
+	errorCauses bool
+}
+
+// TraceIDs returns OpenTelemetry trace and span IDs from context.
+func (t tuples) TraceIDs() (traceID, spanID string) {
+	sc := trace.SpanContextFromContext(t.ctx)
+	if !sc.IsValid() {
+		return "", ""
+	}
+
+	return sc.TraceID().String(), sc.SpanID().String()
 }
 
 func (t tuples) MarshalJSON() ([]byte, error) { //nolint:funlen,cyclop
@@ -57,6 +99,13 @@ func (t tuples) MarshalJSON() ([]byte, error) { //nolint:funlen,cyclop
 			case error:
 				l = v.Error()
 
+				// Cause is only recorded if it adds information to error message.
+				if t.errorCauses {
+					if c := rootCause(v); c.wrapped || c.Sentinel != "" {
+						m[label+"Cause"] = c
+					}
+				}
+
 				var se ctxd.StructuredError
 
 				if errors.As(v, &se) {
@@ -95,35 +144,49 @@ func (t tuples) MarshalJSON() ([]byte, error) { //nolint:funlen,cyclop
 
 // Debug logs debug message.
 func (o Observer) Debug(ctx context.Context, msg string, keysAndValues ...interface{}) {
-	o.debug.ObserveMessage(msg, tuples{ctx: ctx, kv: keysAndValues})
-	o.logger.Debug(ctx, msg, keysAndValues...)
+	if o.debug.ObserveMessageDedup(o.family(msg, keysAndValues), o.data(ctx, keysAndValues), o.repeated.debug) {
+		o.logger.Debug(ctx, msg, keysAndValues...)
+	}
 }
 
 // Info logs informational message.
 func (o Observer) Info(ctx context.Context, msg string, keysAndValues ...interface{}) {
-	o.info.ObserveMessage(msg, tuples{ctx: ctx, kv: keysAndValues})
-	o.logger.Info(ctx, msg, keysAndValues...)
+	if o.info.ObserveMessageDedup(o.family(msg, keysAndValues), o.data(ctx, keysAndValues), o.repeated.info) {
+		o.logger.Info(ctx, msg, keysAndValues...)
+	}
 }
 
 // Important logs important information.
 func (o Observer) Important(ctx context.Context, msg string, keysAndValues ...interface{}) {
-	o.important.ObserveMessage(msg, tuples{ctx: ctx, kv: keysAndValues})
-	o.logger.Important(ctx, msg, keysAndValues...)
+	if o.important.ObserveMessageDedup(o.family(msg, keysAndValues), o.data(ctx, keysAndValues), o.repeated.important) {
+		o.logger.Important(ctx, msg, keysAndValues...)
+	}
 }
 
 // Warn logs a warning.
 func (o Observer) Warn(ctx context.Context, msg string, keysAndValues ...interface{}) {
-	o.warn.ObserveMessage(msg, tuples{ctx: ctx, kv: keysAndValues})
-	o.logger.Warn(ctx, msg, keysAndValues...)
+	if o.warn.ObserveMessageDedup(o.family(msg, keysAndValues), o.data(ctx, keysAndValues), o.repeated.warn) {
+		o.logger.Warn(ctx, msg, keysAndValues...)
+	}
 }
 
 // Error logs an error.
 func (o Observer) Error(ctx context.Context, msg string, keysAndValues ...interface{}) {
-	o.error.ObserveMessage(msg, tuples{ctx: ctx, kv: keysAndValues})
-	o.logger.Error(ctx, msg, keysAndValues...)
+	if o.error.ObserveMessageDedup(o.family(msg, keysAndValues), o.data(ctx, keysAndValues), o.repeated.error) {
+		o.logger.Error(ctx, msg, keysAndValues...)
+	}
+}
+
+// data returns a function to build sample data.
+func (o Observer) data(ctx context.Context, keysAndValues []interface{}) func() interface{} {
+	errorCauses := o.errorCauses
+
+	return func() interface{} {
+		return tuples{ctx: ctx, kv: keysAndValues, errorCauses: errorCauses}
+	}
 }
 
-// LevelObservers returns .
+// LevelObservers returns observers of all levels.
 func (o Observer) LevelObservers() []*logz.Observer {
 	return []*logz.Observer{o.debug, o.info, o.important, o.warn, o.error}
 }
@@ -131,37 +194,99 @@ func (o Observer) LevelObservers() []*logz.Observer {
 // WithLogger returns a copy of Observer with logger, level buckets remain the same.
 func (o Observer) WithLogger(l ctxd.Logger) Observer {
 	o.logger = l
+	o.repeated = newRepeated(l)
+
+	return o
+}
+
+// WithErrorCauses returns a copy of Observer that groups messages by root cause of the first error value.
+//
+// Error chain is unwrapped to the root cause, well-known sentinels like context.Canceled are recognized
+// anywhere in the chain, for example "request failed" is observed as "request failed [context.Canceled]".
+func (o Observer) WithErrorCauses(enabled bool) Observer {
+	o.errorCauses = enabled
 
 	return o
 }
 
+func (o Observer) family(msg string, keysAndValues []interface{}) string {
+	if o.errorCauses {
+		return causeFamily(msg, keysAndValues)
+	}
+
+	return msg
+}
+
 // CtxdLogger is a service provider.
 func (o Observer) CtxdLogger() ctxd.Logger {
 	return o
 }
 
-// NewObserver initializes Observer instance.
+// Level names of observers.
+const (
+	LevelDebug     = "Debug"
+	LevelInfo      = "Info"
+	LevelImportant = "Important"
+	LevelWarning   = "Warning"
+	LevelError     = "Error"
+)
+
+// Options configures Observer.
+type Options struct {
+	// LevelConfigs are used instead of common config for particular levels,
+	// for example to keep more samples for errors or to disable observing debug messages.
+	// Keys are level names: LevelDebug, LevelInfo, LevelImportant, LevelWarning, LevelError.
+	LevelConfigs map[string]logz.Config
+}
+
+// NewObserver initializes Observer instance, first config is used for all levels, extra configs are ignored.
+//
+// Use New to configure levels separately.
 func NewObserver(logger ctxd.Logger, conf ...logz.Config) Observer {
+	cfg := logz.Config{}
+
+	if len(conf) > 0 {
+		cfg = conf[0]
+	}
+
+	return New(logger, cfg)
+}
+
+// New initializes Observer instance with common config and options.
+//
+// Unknown level names in Options.LevelConfigs are ignored.
+func New(logger ctxd.Logger, cfg logz.Config, options ...func(o *Options)) Observer {
+	var opts Options
+
+	for _, option := range options {
+		option(&opts)
+	}
+
 	o := Observer{
-		logger: logger,
+		logger:   logger,
+		repeated: newRepeated(logger),
 	}
 
-	cfg := logz.Config{}
+	level := func(name string) *logz.Observer {
+		c, ok := opts.LevelConfigs[name]
+		if !ok {
+			c = cfg
+		}
 
-	if len(conf) == 1 {
-		cfg = conf[0]
+		c.Name = name
+
+		return &logz.Observer{Config: c}
 	}
 
-	cfg.Name = "Debug"
-	o.debug = &logz.Observer{Config: cfg}
-	cfg.Name = "Info"
-	o.info = &logz.Observer{Config: cfg}
-	cfg.Name = "Important"
-	o.important = &logz.Observer{Config: cfg}
-	cfg.Name = "Warning"
-	o.warn = &logz.Observer{Config: cfg}
-	cfg.Name = "Error"
-	o.error = &logz.Observer{Config: cfg}
+	o.debug = level(LevelDebug)
+	o.info = level(LevelInfo)
+	o.important = level(LevelImportant)
+	o.warn = level(LevelWarning)
+	o.error = level(LevelError)
+
+	if cfg.Registry != nil {
+		cfg.Registry.Add(cfg.Group, o.LevelObservers()...)
+	}
 
 	return o
 }
@@ -2,15 +2,22 @@ package ctxz_test
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/bool64/ctxd"
+	"github.com/bool64/logz"
 	"github.com/bool64/logz/ctxz"
 	"github.com/bool64/logz/logzpage"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestNewObserver(t *testing.T) {
@@ -46,3 +53,193 @@ func TestNewObserver(t *testing.T) {
  &#34;shared&#34;: 123
 }`)
 }
+
+func TestNewObserver_levelConfigs(t *testing.T) {
+	o := ctxz.New(ctxd.NoOpLogger{}, logz.Config{MaxSamples: 5}, func(o *ctxz.Options) {
+		o.LevelConfigs = map[string]logz.Config{
+			ctxz.LevelError: {MaxSamples: 20},
+			ctxz.LevelDebug: {Disabled: true},
+		}
+	})
+
+	ctx := context.Background()
+
+	for i := 0; i < 30; i++ {
+		o.Debug(ctx, "debug", "i", i)
+		o.Info(ctx, "info", "i", i)
+		o.Error(ctx, "error", "i", i)
+
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	levels := o.LevelObservers()
+	assert.Equal(t, "Debug", levels[0].Name)
+	assert.Empty(t, levels[0].GetEntries())
+	assert.Len(t, levels[1].Find("info").Samples, 5)
+	assert.Len(t, levels[4].Find("error").Samples, 20)
+}
+
+func TestNewObserver_commonConfig(t *testing.T) {
+	o := ctxz.NewObserver(ctxd.NoOpLogger{}, logz.Config{Name: "api", MaxCardinality: 2})
+
+	for _, l := range o.LevelObservers() {
+		assert.Equal(t, uint32(2), l.MaxCardinality, l.Name)
+	}
+
+	assert.Equal(t, "Warning", o.LevelObservers()[3].Name)
+
+	// Extra configs and unknown levels are ignored.
+	o = ctxz.NewObserver(ctxd.NoOpLogger{}, logz.Config{MaxCardinality: 3}, logz.Config{MaxCardinality: 4})
+	assert.Equal(t, uint32(3), o.LevelObservers()[0].MaxCardinality)
+
+	o = ctxz.New(ctxd.NoOpLogger{}, logz.Config{MaxCardinality: 3}, func(o *ctxz.Options) {
+		o.LevelConfigs = map[string]logz.Config{"Warn": {MaxCardinality: 4}}
+	})
+	assert.Equal(t, uint32(3), o.LevelObservers()[3].MaxCardinality)
+}
+
+func TestObserver_traceIDs(t *testing.T) {
+	o := ctxz.NewObserver(ctxd.NoOpLogger{})
+
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{0x01, 0x02, 0x03},
+		SpanID:  trace.SpanID{0x04, 0x05},
+	}))
+
+	o.Error(ctx, "failed")
+
+	sample := o.LevelObservers()[4].Find("failed").Samples[0]
+	assert.Equal(t, "01020300000000000000000000000000", sample.TraceID)
+	assert.Equal(t, "0405000000000000", sample.SpanID)
+
+	req, err := http.NewRequest(http.MethodGet, "/debug/logz?level=Error&msg=failed", nil)
+	require.NoError(t, err)
+
+	rw := httptest.NewRecorder()
+
+	logzpage.NewHandler(logzpage.Config{
+		Observers: o.LevelObservers(),
+		TraceURL:  "https://jaeger.example.com/trace/{trace_id}?uiFind={span_id}",
+	}).ServeHTTP(rw, req)
+
+	assert.Contains(t, rw.Body.String(),
+		`<a href="https://jaeger.example.com/trace/01020300000000000000000000000000?uiFind=0405000000000000" target="_blank">`)
+}
+
+func TestNewObserver_repeatLimit(t *testing.T) {
+	logger := &ctxd.LoggerMock{}
+	o := ctxz.NewObserver(logger, logz.Config{RepeatLimit: 2, RepeatWindow: 50 * time.Millisecond})
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		o.Error(ctx, "storm", "i", i)
+	}
+
+	assert.Equal(t, uint64(5), o.LevelObservers()[4].Find("storm").Count)
+
+	assert.Eventually(t, func() bool {
+		logger.Lock()
+		defer logger.Unlock()
+
+		return len(logger.LoggedEntries) == 3
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, "message repeated 3 times: storm", logger.LoggedEntries[2].Message)
+	assert.Equal(t, "error", logger.LoggedEntries[2].Level)
+}
+
+func TestObserver_mute(t *testing.T) {
+	logger := &ctxd.LoggerMock{}
+	o := ctxz.NewObserver(logger)
+	ctx := context.Background()
+
+	o.Warn(ctx, "noisy")
+	assert.True(t, o.LevelObservers()[3].Mute("noisy", time.Minute))
+	o.Warn(ctx, "noisy")
+
+	assert.Equal(t, uint64(2), o.LevelObservers()[3].Find("noisy").Count)
+	assert.Len(t, logger.LoggedEntries, 1)
+}
+
+type joined []error
+
+func (j joined) Error() string   { return "joined" }
+func (j joined) Unwrap() []error { return j }
+
+type cyclic struct{}
+
+func (c *cyclic) Error() string { return "cyclic" }
+func (c *cyclic) Unwrap() error { return c }
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string { return "timeout" }
+
+func TestObserver_WithErrorCauses(t *testing.T) {
+	o := ctxz.NewObserver(ctxd.NoOpLogger{}).WithErrorCauses(true)
+	ctx := context.Background()
+
+	o.Error(ctx, "request failed", "error", fmt.Errorf("do: %w", context.Canceled))
+	o.Error(ctx, "request failed", "error", fmt.Errorf("read: %w", fmt.Errorf("wait: %w", context.DeadlineExceeded)))
+	o.Error(ctx, "request failed", "error", joined{fmt.Errorf("call: %w", timeoutError{}), errors.New("other")})
+	o.Error(ctx, "request failed", "error", fmt.Errorf("query: %w", errors.New("no rows")))
+	o.Error(ctx, "request failed", "code", 500)
+
+	for i := 0; i < 3; i++ {
+		o.Error(ctx, "request failed", "error", fmt.Errorf("user %d not found", 1000+i))
+	}
+
+	o.Error(ctx, "request failed", "error", joined{errors.New("first"), fmt.Errorf("read: %w", io.EOF)})
+
+	entries := o.LevelObservers()[4].GetEntries()
+	messages := make([]string, 0, len(entries))
+
+	for _, e := range entries {
+		messages = append(messages, e.Message)
+	}
+
+	assert.ElementsMatch(t, []string{
+		"request failed [context.Canceled]",
+		"request failed [context.DeadlineExceeded]",
+		"request failed [ctxz_test.timeoutError]",
+		`request failed ["no rows"]`,
+		`request failed ["user X not found"]`,
+		"request failed [io.EOF]",
+		"request failed",
+	}, messages)
+
+	assert.Equal(t, uint64(3), o.LevelObservers()[4].Find(`request failed ["user X not found"]`).Count)
+
+	// Root cause is found in the branch of joined error that matches sentinel.
+	e := o.LevelObservers()[4].Find("request failed [io.EOF]")
+	require.Len(t, e.Samples, 1)
+
+	j, err := json.Marshal(e.Samples[0].Data)
+	require.NoError(t, err)
+	assert.Contains(t, string(j), `"errorCause":{"type":"*errors.errorString","sentinel":"io.EOF","message":"EOF"}`)
+
+	e = o.LevelObservers()[4].Find("request failed [context.DeadlineExceeded]")
+	require.Len(t, e.Samples, 1)
+
+	j, err = json.Marshal(e.Samples[0].Data)
+	require.NoError(t, err)
+	assert.Contains(t, string(j), `"errorCause":{"type":"context.deadlineExceededError",`+
+		`"sentinel":"context.DeadlineExceeded","message":"context deadline exceeded"}`)
+
+	// Cyclic chain is unwrapped to a limited depth.
+	o.Error(ctx, "loop", "error", &cyclic{})
+	assert.NotEmpty(t, o.LevelObservers()[4].Find("loop [*ctxz_test.cyclic]").Samples)
+}
+
+func TestObserver_errorCauseDisabled(t *testing.T) {
+	o := ctxz.NewObserver(ctxd.NoOpLogger{})
+
+	o.Error(context.Background(), "request failed", "error", fmt.Errorf("do: %w", context.Canceled))
+
+	e := o.LevelObservers()[4].Find("request failed")
+	require.Len(t, e.Samples, 1)
+
+	j, err := json.Marshal(e.Samples[0].Data)
+	require.NoError(t, err)
+	assert.NotContains(t, string(j), "errorCause")
+}
@@ -0,0 +1,239 @@
+package logz
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DigestConfig configures periodic digest of observed message families.
+type DigestConfig struct {
+	// Observers are level observers to report, they are ignored if Registry is set.
+	Observers []*Observer
+
+	// Registry provides groups of level observers to report.
+	Registry *Registry
+
+	// Interval is a period of reports.
+	// Default 1 minute.
+	Interval time.Duration
+
+	// Top limits number of most frequent families in a report of a level.
+	// Default 10.
+	Top int
+
+	// Writer receives reports as JSON lines.
+	Writer io.Writer
+
+	// Report receives reports, for example to write them to a logger as a single structured line.
+	Report func(r DigestReport)
+}
+
+// DigestReport summarizes message families observed in an interval.
+type DigestReport struct {
+	From   time.Time     `json:"from"`
+	To     time.Time     `json:"to"`
+	Levels []DigestLevel `json:"levels"`
+}
+
+// DigestLevel summarizes message families of an observer.
+type DigestLevel struct {
+	Group string `json:"group,omitempty"`
+	Name  string `json:"name"`
+
+	// Count is a total number of events in the interval.
+	Count uint64 `json:"count"`
+
+	// Top contains most frequent families in the interval.
+	Top []DigestFamily `json:"top,omitempty"`
+
+	// New contains families that were first observed in the interval.
+	New []string `json:"new,omitempty"`
+
+	// Other is a number of events that exceeded cardinality limit in the interval.
+	Other uint64 `json:"other,omitempty"`
+}
+
+// DigestFamily is a count of events of message family.
+type DigestFamily struct {
+	Message string `json:"msg"`
+	Count   uint64 `json:"count"`
+}
+
+// Digest periodically reports summary of observed message families.
+//
+// It gives aggregation of logz for batch jobs and CLIs that do not expose logz page.
+type Digest struct {
+	cfg DigestConfig
+
+	mu    sync.Mutex
+	from  time.Time
+	prev  map[*Observer]map[string]uint64
+	other map[*Observer]uint64
+
+	started  bool
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// StartDigest starts periodic reports.
+func StartDigest(cfg DigestConfig) *Digest {
+	d := NewDigest(cfg)
+	d.started = true
+
+	go d.run()
+
+	return d
+}
+
+// NewDigest creates Digest without starting periodic reports, use Report to make reports.
+//
+// Events observed before NewDigest are not reported.
+func NewDigest(cfg DigestConfig) *Digest {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Minute
+	}
+
+	if cfg.Top <= 0 {
+		cfg.Top = 10
+	}
+
+	d := &Digest{
+		cfg:   cfg,
+		from:  time.Now(),
+		prev:  make(map[*Observer]map[string]uint64),
+		other: make(map[*Observer]uint64),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+
+	// Current counts are a baseline for the first report.
+	d.Report()
+
+	return d
+}
+
+func (d *Digest) run() {
+	defer close(d.done)
+
+	ticker := time.NewTicker(d.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			d.emit(d.Report())
+		case <-d.stop:
+			d.emit(d.Report())
+
+			return
+		}
+	}
+}
+
+// Stop stops periodic reports and emits a final report of events since previous report.
+//
+// It does nothing for Digest that was not started with StartDigest.
+func (d *Digest) Stop() {
+	if !d.started {
+		return
+	}
+
+	d.stopOnce.Do(func() { close(d.stop) })
+	<-d.done
+}
+
+func (d *Digest) emit(r DigestReport) {
+	if len(r.Levels) == 0 {
+		return
+	}
+
+	if d.cfg.Writer != nil {
+		enc := json.NewEncoder(d.cfg.Writer)
+		enc.SetEscapeHTML(false)
+
+		_ = enc.Encode(r) //nolint:errcheck // Best effort.
+	}
+
+	if d.cfg.Report != nil {
+		d.cfg.Report(r)
+	}
+}
+
+func (d *Digest) groups() []Group {
+	if d.cfg.Registry != nil {
+		return d.cfg.Registry.Groups()
+	}
+
+	return []Group{{Observers: d.cfg.Observers}}
+}
+
+// Report summarizes events since previous report, levels without events are omitted.
+func (d *Digest) Report() DigestReport {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	r := DigestReport{From: d.from, To: time.Now()}
+	d.from = r.To
+
+	for _, g := range d.groups() {
+		for _, o := range g.Observers {
+			if o.Disabled {
+				continue
+			}
+
+			if l := d.level(o); l.Count > 0 {
+				l.Group = g.Name
+				r.Levels = append(r.Levels, l)
+			}
+		}
+	}
+
+	return r
+}
+
+func (d *Digest) level(o *Observer) DigestLevel {
+	l := DigestLevel{Name: o.Name}
+	prev := d.prev[o]
+	counts := make(map[string]uint64, len(prev))
+
+	for _, e := range o.GetEntries() {
+		counts[e.Message] = e.Count
+
+		p, ok := prev[e.Message]
+		if !ok {
+			l.New = append(l.New, e.Message)
+		}
+
+		if e.Count > p {
+			l.Top = append(l.Top, DigestFamily{Message: e.Message, Count: e.Count - p})
+			l.Count += e.Count - p
+		}
+	}
+
+	other := o.Other(false).Count
+	l.Other = other - d.other[o]
+	l.Count += l.Other
+
+	d.prev[o] = counts
+	d.other[o] = other
+
+	sort.Slice(l.Top, func(i, j int) bool {
+		if l.Top[i].Count != l.Top[j].Count {
+			return l.Top[i].Count > l.Top[j].Count
+		}
+
+		return l.Top[i].Message < l.Top[j].Message
+	})
+
+	if len(l.Top) > d.cfg.Top {
+		l.Top = l.Top[:d.cfg.Top]
+	}
+
+	sort.Strings(l.New)
+
+	return l
+}
@@ -0,0 +1,88 @@
+package logz_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/bool64/logz"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDigest_Report(t *testing.T) {
+	var r logz.Registry
+
+	o := &logz.Observer{Config: logz.Config{Name: "Error", MaxCardinality: 2}}
+	r.Add("app", o)
+
+	d := logz.NewDigest(logz.DigestConfig{Registry: &r, Top: 1})
+
+	o.ObserveMessage("failed", nil)
+	o.ObserveMessage("failed", nil)
+	o.ObserveMessage("timeout", nil)
+	o.ObserveMessage("overflow", nil)
+
+	rep := d.Report()
+	require.Len(t, rep.Levels, 1)
+
+	l := rep.Levels[0]
+	assert.Equal(t, "app", l.Group)
+	assert.Equal(t, "Error", l.Name)
+	assert.Equal(t, uint64(4), l.Count)
+	assert.Equal(t, []logz.DigestFamily{{Message: "failed", Count: 2}}, l.Top)
+	assert.Equal(t, []string{"failed", "timeout"}, l.New)
+	assert.Equal(t, uint64(1), l.Other)
+
+	o.ObserveMessage("timeout", nil)
+
+	rep = d.Report()
+	require.Len(t, rep.Levels, 1)
+	assert.Equal(t, uint64(1), rep.Levels[0].Count)
+	assert.Equal(t, []logz.DigestFamily{{Message: "timeout", Count: 1}}, rep.Levels[0].Top)
+	assert.Empty(t, rep.Levels[0].New)
+
+	assert.Empty(t, d.Report().Levels)
+}
+
+func TestStartDigest(t *testing.T) {
+	o := &logz.Observer{Config: logz.Config{Name: "Info"}}
+	buf := bytes.NewBuffer(nil)
+
+	d := logz.StartDigest(logz.DigestConfig{
+		Observers: []*logz.Observer{o},
+		Interval:  time.Hour,
+		Writer:    buf,
+	})
+
+	o.ObserveMessage("started", nil)
+	d.Stop()
+
+	var rep logz.DigestReport
+
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &rep))
+	require.Len(t, rep.Levels, 1)
+	assert.Equal(t, "started", rep.Levels[0].Top[0].Message)
+}
+
+func TestNewDigest_baseline(t *testing.T) {
+	o := &logz.Observer{Config: logz.Config{Name: "Error", MaxCardinality: 1}}
+
+	o.ObserveMessage("old", nil)
+	o.ObserveMessage("old", nil)
+	o.ObserveMessage("overflow", nil)
+
+	d := logz.NewDigest(logz.DigestConfig{Observers: []*logz.Observer{o}})
+
+	o.ObserveMessage("old", nil)
+
+	rep := d.Report()
+	require.Len(t, rep.Levels, 1)
+	assert.Equal(t, uint64(1), rep.Levels[0].Count)
+	assert.Empty(t, rep.Levels[0].New)
+	assert.Zero(t, rep.Levels[0].Other)
+
+	// Stop does not block for digest that was not started.
+	d.Stop()
+}
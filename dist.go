@@ -0,0 +1,113 @@
+package logz
+
+import (
+	"sync"
+
+	"github.com/vearutop/dynhist-go"
+)
+
+// distribution tracks events in time, time values are in sampling interval units.
+type distribution interface {
+	add(now int64)
+	buckets(now int64) []bucket
+}
+
+type bucket struct {
+	from, to int64
+	count    uint64
+}
+
+// adaptiveDist keeps a limited number of buckets with irregular width, merging adjacent buckets when needed.
+type adaptiveDist struct {
+	collector       *dynhist.Collector
+	retentionPeriod int64
+}
+
+func newAdaptiveDist(resolution int, retentionPeriod int64) *adaptiveDist {
+	return &adaptiveDist{
+		collector: &dynhist.Collector{
+			BucketsLimit: resolution,
+		},
+		retentionPeriod: retentionPeriod,
+	}
+}
+
+func (d *adaptiveDist) add(now int64) {
+	d.collector.Add(float64(now))
+
+	if d.retentionPeriod > 0 {
+		d.collector.Lock()
+		if int64(d.collector.Buckets[0].Min) < now-d.retentionPeriod {
+			d.collector.Buckets = append(d.collector.Buckets[:0:0], d.collector.Buckets[1:]...)
+		}
+		d.collector.Unlock()
+	}
+}
+
+func (d *adaptiveDist) buckets(_ int64) []bucket {
+	d.collector.Lock()
+	defer d.collector.Unlock()
+
+	res := make([]bucket, 0, len(d.collector.Buckets))
+
+	for _, b := range d.collector.Buckets {
+		res = append(res, bucket{
+			from:  int64(b.Min),
+			to:    int64(b.Max),
+			count: uint64(b.Count),
+		})
+	}
+
+	return res
+}
+
+// fixedDist keeps a ring of buckets of the same width, so that distributions can be aligned.
+type fixedDist struct {
+	mu    sync.Mutex
+	width int64
+	slots []slot
+}
+
+func newFixedDist(resolution int, width int64) *fixedDist {
+	if width < 1 {
+		width = 1
+	}
+
+	return &fixedDist{
+		width: width,
+		slots: make([]slot, resolution),
+	}
+}
+
+func (d *fixedDist) add(now int64) {
+	// Slots start at the Unix epoch, earlier events are not counted.
+	if now < 0 {
+		return
+	}
+
+	at := now / d.width
+
+	d.slots[at%int64(len(d.slots))].add(at, &d.mu)
+}
+
+// buckets returns all buckets of the ring ending with the one that contains now, empty buckets included.
+func (d *fixedDist) buckets(now int64) []bucket {
+	last := now / d.width
+	first := last - int64(len(d.slots)) + 1
+	res := make([]bucket, 0, len(d.slots))
+
+	for at := first; at <= last; at++ {
+		b := bucket{
+			from: at * d.width,
+			to:   (at + 1) * d.width,
+		}
+
+		if at >= 0 {
+			b.count = d.slots[at%int64(len(d.slots))].get(at)
+		}
+
+		res = append(res, b)
+	}
+
+	return res
+}
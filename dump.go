@@ -0,0 +1,115 @@
+package logz
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Dump contains observed entries with samples, for example to investigate a crash after process exit.
+type Dump struct {
+	Time   time.Time
+	Reason string
+	Groups []DumpGroup
+}
+
+// DumpGroup contains observers of a group.
+type DumpGroup struct {
+	Name      string
+	Observers []DumpObserver
+}
+
+// DumpObserver contains entries of an observer.
+type DumpObserver struct {
+	Name    string
+	Entries []Entry
+	Other   Entry
+}
+
+// NewDump collects entries with samples of observers in groups.
+func NewDump(reason string, groups ...Group) Dump {
+	d := Dump{
+		Time:   time.Now(),
+		Reason: reason,
+		Groups: make([]DumpGroup, 0, len(groups)),
+	}
+
+	for _, g := range groups {
+		dg := DumpGroup{Name: g.Name}
+
+		for _, o := range g.Observers {
+			if o.Disabled {
+				continue
+			}
+
+			dg.Observers = append(dg.Observers, DumpObserver{
+				Name:    o.Name,
+				Entries: o.GetEntriesWithSamples(),
+				Other:   o.Other(true),
+			})
+		}
+
+		d.Groups = append(d.Groups, dg)
+	}
+
+	return d
+}
+
+// WriteDump writes JSON dump of observers in groups as a single line.
+//
+// Writer is synced if it has Sync method, for example *os.File.
+func WriteDump(w io.Writer, reason string, groups ...Group) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(NewDump(reason, groups...)); err != nil {
+		return err
+	}
+
+	if s, ok := w.(interface{ Sync() error }); ok {
+		return s.Sync()
+	}
+
+	return nil
+}
+
+// DumpOnPanic writes dump of registered groups to w and continues panicking.
+//
+// It must be deferred directly, for example in main or in a goroutine function:
+//
+//	defer registry.DumpOnPanic(logz.DumpFile("/var/log/app/logz-crash.json"))
+func (r *Registry) DumpOnPanic(w io.Writer) {
+	if p := recover(); p != nil {
+		_ = WriteDump(w, fmt.Sprintf("panic: %v", p), r.Groups()...) //nolint:errcheck // Panic is more important.
+
+		panic(p)
+	}
+}
+
+// DumpFile returns writer that appends to file at path, file is created on first write,
+// so that it only exists if there was a dump.
+func DumpFile(path string) io.Writer {
+	return dumpFile(path)
+}
+
+type dumpFile string
+
+func (d dumpFile) Write(p []byte) (int, error) {
+	f, err := os.OpenFile(string(d), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := f.Write(p)
+	if err == nil {
+		err = f.Sync()
+	}
+
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	return n, err
+}
@@ -0,0 +1,56 @@
+package logz_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bool64/logz"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteDump(t *testing.T) {
+	o := &logz.Observer{Config: logz.Config{Name: "Error"}}
+	d := &logz.Observer{Config: logz.Config{Name: "Debug", Disabled: true}}
+
+	o.ObserveMessage("failed", 123)
+
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, logz.WriteDump(buf, "test", logz.Group{Name: "app", Observers: []*logz.Observer{d, o}}))
+
+	var dump logz.Dump
+
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &dump))
+	assert.Equal(t, "test", dump.Reason)
+	require.Len(t, dump.Groups, 1)
+	assert.Equal(t, "app", dump.Groups[0].Name)
+	require.Len(t, dump.Groups[0].Observers, 1)
+	assert.Equal(t, "Error", dump.Groups[0].Observers[0].Name)
+	require.Len(t, dump.Groups[0].Observers[0].Entries, 1)
+	assert.Equal(t, "failed", dump.Groups[0].Observers[0].Entries[0].Message)
+	assert.Equal(t, 123.0, dump.Groups[0].Observers[0].Entries[0].Samples[0].Data)
+}
+
+func TestRegistry_DumpOnPanic(t *testing.T) {
+	var r logz.Registry
+
+	o := &logz.Observer{Config: logz.Config{Name: "Error"}}
+	r.Add("app", o)
+	o.ObserveMessage("failed", nil)
+
+	path := filepath.Join(t.TempDir(), "crash.json")
+
+	assert.PanicsWithValue(t, "oops", func() {
+		defer r.DumpOnPanic(logz.DumpFile(path))
+
+		panic("oops")
+	})
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"Reason":"panic: oops"`)
+	assert.Contains(t, string(b), `"Message":"failed"`)
+}
@@ -0,0 +1,101 @@
+package logz
+
+import (
+	"expvar"
+	"sync/atomic"
+)
+
+// Stats contains counters of an observer.
+type Stats struct {
+	// Families contains counts of events by message family.
+	Families map[string]uint64 `json:"families"`
+
+	// Cardinality is a number of tracked message families.
+	Cardinality uint32 `json:"cardinality"`
+
+	// MaxCardinality is a limit of tracked message families.
+	MaxCardinality uint32 `json:"max_cardinality"`
+
+	// Other is a number of events that exceeded cardinality limit.
+	Other uint64 `json:"other"`
+}
+
+// Stats returns counters of observer.
+func (l *Observer) Stats() Stats {
+	l.once.Do(func() {
+		l.initialize(l.Config)
+	})
+
+	return l.PreparedObserver.Stats()
+}
+
+// Stats returns counters of observer.
+func (l *PreparedObserver) Stats() Stats {
+	s := Stats{
+		Families:       make(map[string]uint64, atomic.LoadUint32(&l.count)),
+		Cardinality:    atomic.LoadUint32(&l.count),
+		MaxCardinality: l.maxCardinality,
+	}
+
+	l.entries.Range(func(_, value interface{}) bool {
+		e := value.(*entry) //nolint:errcheck // Only entries are stored.
+		s.Families[e.msg] = atomic.LoadUint64(&e.count)
+
+		return true
+	})
+
+	if l.other != nil {
+		s.Other = atomic.LoadUint64(&l.other.count)
+	}
+
+	return s
+}
+
+// PublishExpvar publishes stats of observers as expvar variables named "<prefix>.<observer name>",
+// default prefix is "logz", for example "logz.Error".
+//
+// Stats are collected when variables are read, for example at /debug/vars.
+// Like expvar.Publish, it panics if a variable with the same name is already published.
+func PublishExpvar(prefix string, observers ...*Observer) {
+	if prefix == "" {
+		prefix = "logz"
+	}
+
+	for _, o := range observers {
+		o := o
+
+		expvar.Publish(prefix+"."+o.Name, expvar.Func(func() interface{} {
+			return o.Stats()
+		}))
+	}
+}
+
+// PublishExpvar publishes stats of registered observers as a single expvar variable,
+// default name is "logz", value is a map of group names to maps of observer names to stats.
+//
+// Groups registered after publishing are also exposed.
+// Like expvar.Publish, it panics if a variable with the same name is already published.
+func (r *Registry) PublishExpvar(name string) {
+	if name == "" {
+		name = "logz"
+	}
+
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		groups := r.Groups()
+		res := make(map[string]map[string]Stats, len(groups))
+
+		for _, g := range groups {
+			levels := make(map[string]Stats, len(g.Observers))
+
+			for _, o := range g.Observers {
+				if !o.Disabled {
+					levels[o.Name] = o.Stats()
+				}
+			}
+
+			res[g.Name] = levels
+		}
+
+		return res
+	}))
+}
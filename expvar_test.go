@@ -0,0 +1,48 @@
+package logz_test
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+
+	"github.com/bool64/logz"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishExpvar(t *testing.T) {
+	o := &logz.Observer{Config: logz.Config{Name: "Error", MaxCardinality: 1}}
+
+	logz.PublishExpvar("test_logz", o)
+
+	o.ObserveMessage("failed", nil)
+	o.ObserveMessage("failed", nil)
+	o.ObserveMessage("overflow", nil)
+
+	var s logz.Stats
+
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get("test_logz.Error").String()), &s))
+	assert.Equal(t, map[string]uint64{"failed": 2}, s.Families)
+	assert.Equal(t, uint32(1), s.Cardinality)
+	assert.Equal(t, uint32(1), s.MaxCardinality)
+	assert.Equal(t, uint64(1), s.Other)
+}
+
+func TestObserver_Stats(t *testing.T) {
+	o := &logz.Observer{}
+
+	assert.Equal(t, uint32(100), o.Stats().MaxCardinality)
+}
+
+func TestRegistry_PublishExpvar(t *testing.T) {
+	var r logz.Registry
+
+	r.PublishExpvar("test_logz_registry")
+
+	o := &logz.Observer{Config: logz.Config{Name: "Warning"}}
+	r.Add("db", o)
+	o.ObserveMessage("slow query", nil)
+
+	assert.JSONEq(t, `{"db":{"Warning":{"families":{"slow query":1},"cardinality":1,"max_cardinality":100,"other":0}}}`,
+		expvar.Get("test_logz_registry").String())
+}
@@ -0,0 +1,70 @@
+package logz
+
+import (
+	"sync"
+
+	"github.com/vearutop/lograte/filter"
+)
+
+const (
+	// maxFilteredLen limits length of filtered family key.
+	maxFilteredLen = 200
+
+	// familyKeysSize limits number of raw messages with cached family keys.
+	familyKeysSize = 10000
+)
+
+// familyKeys caches family keys of raw messages filtered with filter.Dynamic.
+//
+// Cache is reset when it reaches size limit, so that messages with many distinct dynamic
+// values can not grow it indefinitely, while frequent messages are quickly cached again.
+type familyKeys struct {
+	size int
+
+	mu   sync.RWMutex
+	keys map[string]string
+}
+
+func newFamilyKeys(size int) *familyKeys {
+	return &familyKeys{
+		size: size,
+		keys: make(map[string]string),
+	}
+}
+
+func (fk *familyKeys) get(msg string) string {
+	fk.mu.RLock()
+	key, ok := fk.keys[msg]
+	fk.mu.RUnlock()
+
+	if ok {
+		return key
+	}
+
+	key = filterMessage(msg)
+
+	fk.mu.Lock()
+
+	if len(fk.keys) >= fk.size {
+		fk.keys = make(map[string]string)
+	}
+
+	fk.keys[msg] = key
+	fk.mu.Unlock()
+
+	return key
+}
+
+// filterMessage returns family key of a message, original message is returned
+// without allocation if it has no dynamic parts.
+func filterMessage(msg string) string {
+	var buf [maxFilteredLen + 1]byte
+
+	key := filter.Dynamic(append(buf[:0], msg...), maxFilteredLen)
+
+	if string(key) == msg {
+		return msg
+	}
+
+	return string(key)
+}
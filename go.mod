@@ -5,15 +5,18 @@ go 1.19
 require (
 	github.com/bool64/ctxd v1.2.1
 	github.com/bool64/dev v0.2.34
+	github.com/go-logr/logr v1.4.2
 	github.com/stretchr/testify v1.8.4
 	github.com/vearutop/dynhist-go v1.2.3
 	github.com/vearutop/lograte v1.1.3
+	go.opentelemetry.io/otel/trace v1.16.0
 	go.uber.org/zap v1.27.0
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
+	go.opentelemetry.io/otel v1.16.0 // indirect
 	go.uber.org/multierr v1.11.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,278 @@
+// Package logrz provides observer for github.com/go-logr/logr.Logger.
+package logrz
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/bool64/logz"
+	"github.com/go-logr/logr"
+)
+
+// LevelError is a name of errors observer.
+const LevelError = "Error"
+
+// Options configures observer sink.
+type Options struct {
+	// MaxVerbosity is the highest verbosity level with a separate observer, default 5.
+	// Messages of higher verbosity are observed with MaxVerbosity.
+	MaxVerbosity int
+
+	// LevelConfigs are used instead of common config for particular levels,
+	// level names are LevelName(verbosity) and LevelError.
+	LevelConfigs map[string]logz.Config
+}
+
+// LevelName returns observer name for verbosity level: "Info" for 0, "V1", "V2" and so on for others.
+func LevelName(verbosity int) string {
+	if verbosity == 0 {
+		return "Info"
+	}
+
+	return "V" + strconv.Itoa(verbosity)
+}
+
+// Sink observes messages and passes them to wrapped sink.
+type Sink struct {
+	sink   logr.LogSink
+	name   string
+	values []interface{}
+
+	// verbosity contains observers by verbosity level.
+	verbosity []*logz.Observer
+	error     *logz.Observer
+}
+
+var (
+	_ logr.LogSink          = Sink{}
+	_ logr.CallDepthLogSink = Sink{}
+)
+
+// NewSink creates observer sink that wraps another sink, wrapped sink can be nil.
+//
+// Observers are returned from the most verbose level to errors.
+func NewSink(sink logr.LogSink, cfg logz.Config, options ...func(o *Options)) (Sink, []*logz.Observer) {
+	opts := Options{}
+
+	for _, option := range options {
+		option(&opts)
+	}
+
+	if opts.MaxVerbosity <= 0 {
+		opts.MaxVerbosity = 5
+	}
+
+	level := func(name string) *logz.Observer {
+		c, ok := opts.LevelConfigs[name]
+		if !ok {
+			c = cfg
+		}
+
+		c.Name = name
+
+		return &logz.Observer{Config: c}
+	}
+
+	s := Sink{
+		sink:      sink,
+		verbosity: make([]*logz.Observer, opts.MaxVerbosity+1),
+		error:     level(LevelError),
+	}
+
+	observers := make([]*logz.Observer, 0, opts.MaxVerbosity+2)
+
+	for v := opts.MaxVerbosity; v >= 0; v-- {
+		s.verbosity[v] = level(LevelName(v))
+		observers = append(observers, s.verbosity[v])
+	}
+
+	observers = append(observers, s.error)
+
+	if cfg.Registry != nil {
+		cfg.Registry.Add(cfg.Group, observers...)
+	}
+
+	return s, observers
+}
+
+// Wrap returns logger that observes messages with per-level observers.
+func Wrap(l logr.Logger, cfg logz.Config, options ...func(o *Options)) (logr.Logger, []*logz.Observer) {
+	s, observers := NewSink(l.GetSink(), cfg, options...)
+
+	return logr.New(s).V(l.GetV()), observers
+}
+
+// Init passes runtime info to wrapped sink.
+func (s Sink) Init(info logr.RuntimeInfo) {
+	if s.sink != nil {
+		// Add a frame of this sink.
+		info.CallDepth++
+		s.sink.Init(info)
+	}
+}
+
+// Enabled tells whether wrapped sink is enabled for verbosity level, all levels are enabled without wrapped sink.
+func (s Sink) Enabled(level int) bool {
+	if s.sink == nil {
+		return true
+	}
+
+	return s.sink.Enabled(level)
+}
+
+// Info observes and logs a non-error message.
+func (s Sink) Info(level int, msg string, keysAndValues ...interface{}) {
+	// Verbosity above maximum is counted by observer of maximum verbosity,
+	// original level is passed to wrapped sink.
+	idx := level
+
+	if idx >= len(s.verbosity) {
+		idx = len(s.verbosity) - 1
+	}
+
+	if idx < 0 {
+		idx = 0
+	}
+
+	s.verbosity[idx].ObserveMessage(s.family(msg), s.data(nil, keysAndValues))
+
+	if s.sink != nil {
+		s.sink.Info(level, msg, keysAndValues...)
+	}
+}
+
+// Error observes and logs an error message.
+func (s Sink) Error(err error, msg string, keysAndValues ...interface{}) {
+	s.error.ObserveMessage(s.family(msg), s.data(err, keysAndValues))
+
+	if s.sink != nil {
+		s.sink.Error(err, msg, keysAndValues...)
+	}
+}
+
+// WithValues returns a sink with additional key-value pairs.
+func (s Sink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	if s.sink != nil {
+		s.sink = s.sink.WithValues(keysAndValues...)
+	}
+
+	s.values = append(s.values[0:len(s.values):len(s.values)], keysAndValues...)
+
+	return s
+}
+
+// WithName returns a sink with a name element added, elements are joined with "/".
+func (s Sink) WithName(name string) logr.LogSink {
+	if s.sink != nil {
+		s.sink = s.sink.WithName(name)
+	}
+
+	if s.name == "" {
+		s.name = name
+	} else {
+		s.name += "/" + name
+	}
+
+	return s
+}
+
+// WithCallDepth passes call depth to wrapped sink.
+func (s Sink) WithCallDepth(depth int) logr.LogSink {
+	if cd, ok := s.sink.(logr.CallDepthLogSink); ok {
+		s.sink = cd.WithCallDepth(depth)
+	}
+
+	return s
+}
+
+// family groups messages of named loggers separately.
+func (s Sink) family(msg string) string {
+	if s.name == "" {
+		return msg
+	}
+
+	return s.name + ": " + msg
+}
+
+func (s Sink) data(err error, keysAndValues []interface{}) data {
+	return data{
+		name:   s.name,
+		err:    err,
+		values: s.values,
+		kv:     keysAndValues,
+	}
+}
+
+type data struct {
+	name   string
+	err    error
+	values []interface{}
+	kv     []interface{}
+}
+
+func (d data) MarshalJSON() ([]byte, error) {
+	m := make(map[string]interface{}, (len(d.values)+len(d.kv))/2+2)
+
+	if d.name != "" {
+		m["logger"] = d.name
+	}
+
+	if d.err != nil {
+		m["error"] = d.err.Error()
+	}
+
+	for _, kv := range [][]interface{}{d.values, d.kv} {
+		for i := 0; i < len(kv); i += 2 {
+			k, ok := kv[i].(string)
+			if !ok {
+				k = fmt.Sprint(kv[i])
+			}
+
+			if i+1 == len(kv) {
+				m[k] = "<no value>"
+
+				break
+			}
+
+			v, err := value(kv[i+1])
+			if err != nil {
+				return nil, err
+			}
+
+			m[k] = v
+		}
+	}
+
+	b := bytes.Buffer{}
+	e := json.NewEncoder(&b)
+
+	e.SetEscapeHTML(false)
+
+	if err := e.Encode(m); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+func value(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case logr.Marshaler:
+		return v.MarshalLog(), nil
+	case error:
+		return v.Error(), nil
+	case json.Marshaler:
+		return v, nil
+	case encoding.TextMarshaler:
+		b, err := v.MarshalText()
+
+		return string(b), err
+	case fmt.Stringer:
+		return v.String(), nil
+	}
+
+	return v, nil
+}
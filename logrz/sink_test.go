@@ -0,0 +1,70 @@
+package logrz_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/bool64/logz"
+	"github.com/bool64/logz/logrz"
+	"github.com/go-logr/logr/funcr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrap(t *testing.T) {
+	var lines []string
+
+	l := funcr.New(func(prefix, args string) {
+		lines = append(lines, prefix+" "+args)
+	}, funcr.Options{Verbosity: 7})
+
+	l, observers := logrz.Wrap(l, logz.Config{}, func(o *logrz.Options) {
+		o.MaxVerbosity = 2
+	})
+
+	require.Len(t, observers, 4)
+	assert.Equal(t, "V2", observers[0].Name)
+	assert.Equal(t, "Info", observers[2].Name)
+	assert.Equal(t, "Error", observers[3].Name)
+
+	db := l.WithName("db").WithValues("table", "users")
+
+	db.Info("query done", "rows", 3)
+	db.V(1).Info("query started")
+	db.V(7).Info("too verbose")
+	db.Error(errors.New("failed"), "query failed", "rows", 0)
+
+	assert.Len(t, lines, 4)
+	assert.Contains(t, lines[0], `db "level"=0 "msg"="query done"`)
+	assert.Contains(t, lines[2], `db "level"=7 "msg"="too verbose"`)
+
+	e := observers[2].Find("db: query done")
+	require.Len(t, e.Samples, 1)
+
+	d, err := json.Marshal(e.Samples[0].Data)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"logger":"db","table":"users","rows":3}`, string(d))
+
+	assert.Equal(t, uint64(1), observers[1].Find("db: query started").Count)
+	assert.Equal(t, uint64(1), observers[0].Find("db: too verbose").Count)
+
+	e = observers[3].Find("db: query failed")
+	require.Len(t, e.Samples, 1)
+
+	d, err = json.Marshal(e.Samples[0].Data)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"logger":"db","error":"failed","table":"users","rows":0}`, string(d))
+}
+
+func TestNewSink(t *testing.T) {
+	r := &logz.Registry{}
+
+	s, observers := logrz.NewSink(nil, logz.Config{Registry: r, Group: "logr"})
+	assert.Len(t, observers, 7)
+	assert.Len(t, r.Group("logr"), 7)
+	assert.True(t, s.Enabled(10))
+
+	s.Info(0, "hello", "key")
+	assert.Equal(t, uint64(1), observers[5].Find("hello").Count)
+}
@@ -0,0 +1,425 @@
+// Package logznotify sends webhook notifications about new and spiking message families.
+package logznotify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bool64/logz"
+)
+
+// Kinds of notifications.
+const (
+	KindNew   = "new"
+	KindSpike = "spike"
+)
+
+// Config configures Notifier.
+type Config struct {
+	// URL receives POST requests with JSON Payload.
+	URL string
+
+	// Client is used to send requests, default client has 10 seconds timeout.
+	Client *http.Client
+
+	// Observers are level observers to watch, they are ignored if Registry is set.
+	Observers []*logz.Observer
+
+	// Registry provides groups of level observers to watch.
+	Registry *logz.Registry
+
+	// Levels are names of observers to watch, names are matched case-insensitively.
+	// Default "Error", "Warning" and "Warn".
+	Levels []string
+
+	// Interval is a period of checks, notifications found in a check are sent in a single request.
+	// Default 10 seconds.
+	Interval time.Duration
+
+	// RateThreshold is a rate of events per minute in a check interval, family with higher rate
+	// is reported as spike. Default 0, spikes are not reported.
+	RateThreshold float64
+
+	// MaxBatch limits number of notifications in a request, extra notifications are sent in next checks.
+	// Default 100.
+	MaxBatch int
+
+	// MaxRetries limits number of retries of failed request.
+	// Default 3, use -1 to disable retries.
+	MaxRetries int
+
+	// Backoff is a delay before first retry, it is doubled for next retries.
+	// Default 1 second.
+	Backoff time.Duration
+
+	// DedupWindow is a period during which same kind of notification is not repeated for a family.
+	// Default 10 minutes.
+	DedupWindow time.Duration
+
+	// OnError receives errors of sending notifications.
+	OnError func(err error)
+}
+
+// Payload is a body of webhook request.
+type Payload struct {
+	Notifications []Notification `json:"notifications"`
+}
+
+// Notification describes new or spiking message family.
+type Notification struct {
+	Kind    string `json:"kind"`
+	Group   string `json:"group,omitempty"`
+	Level   string `json:"level"`
+	Message string `json:"msg"`
+
+	// Count is a total number of events.
+	Count uint64 `json:"count"`
+
+	// Rate is a number of events per minute in check interval.
+	Rate float64 `json:"rate"`
+
+	// Sample is the latest sample of the family.
+	Sample *logz.Sample `json:"sample,omitempty"`
+}
+
+// Notifier watches observers and sends notifications to webhook.
+type Notifier struct {
+	cfg Config
+
+	mu       sync.Mutex
+	prevTime time.Time
+	prev     map[*logz.Observer]map[string]uint64
+	notified map[string]time.Time
+
+	// pending are notifications that did not fit in MaxBatch of previous check.
+	pending []Notification
+
+	started  bool
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// New creates Notifier without starting periodic checks, use Check to make checks.
+//
+// Families observed before New are not reported as new.
+func New(cfg Config) *Notifier {
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	if cfg.Levels == nil {
+		cfg.Levels = []string{"Error", "Warning", "Warn"}
+	}
+
+	if cfg.Interval <= 0 {
+		cfg.Interval = 10 * time.Second
+	}
+
+	if cfg.MaxBatch <= 0 {
+		cfg.MaxBatch = 100
+	}
+
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 3
+	}
+
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = time.Second
+	}
+
+	if cfg.DedupWindow <= 0 {
+		cfg.DedupWindow = 10 * time.Minute
+	}
+
+	n := &Notifier{
+		cfg:      cfg,
+		prevTime: time.Now(),
+		prev:     make(map[*logz.Observer]map[string]uint64),
+		notified: make(map[string]time.Time),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	for _, g := range n.groups() {
+		for _, o := range g.Observers {
+			if n.watched(o) {
+				n.prev[o] = counts(o)
+			}
+		}
+	}
+
+	return n
+}
+
+func counts(o *logz.Observer) map[string]uint64 {
+	entries := o.GetEntries()
+	res := make(map[string]uint64, len(entries))
+
+	for _, e := range entries {
+		res[e.Message] = e.Count
+	}
+
+	return res
+}
+
+// Start creates Notifier and starts periodic checks.
+func Start(cfg Config) *Notifier {
+	n := New(cfg)
+	n.started = true
+
+	go n.run()
+
+	return n
+}
+
+// Stop stops periodic checks.
+//
+// It does nothing for Notifier that was not started with Start.
+func (n *Notifier) Stop() {
+	if !n.started {
+		return
+	}
+
+	n.stopOnce.Do(func() { close(n.stop) })
+	<-n.done
+}
+
+func (n *Notifier) run() {
+	defer close(n.done)
+
+	ticker := time.NewTicker(n.cfg.Interval)
+	defer ticker.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		<-n.stop
+		cancel()
+	}()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := n.Check(ctx); err != nil && n.cfg.OnError != nil && ctx.Err() == nil {
+				n.cfg.OnError(err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Check finds new and spiking families since previous check and sends notifications.
+//
+// State of observed families is only updated after successful sending, so that failed notifications
+// are repeated in the next check. Notifications rejected by webhook with 4xx status are dropped,
+// error is returned once. Notifications that exceed MaxBatch are sent in next checks.
+func (n *Notifier) Check(ctx context.Context) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := time.Now()
+	notifications, prev := n.collect(now)
+
+	if len(notifications) == 0 {
+		n.commit(now, prev, nil)
+
+		return nil
+	}
+
+	var overflow []Notification
+
+	if len(notifications) > n.cfg.MaxBatch {
+		overflow = notifications[n.cfg.MaxBatch:]
+		notifications = notifications[:n.cfg.MaxBatch]
+	}
+
+	err := n.send(ctx, Payload{Notifications: notifications})
+	if err != nil && !errors.Is(err, errPermanent) {
+		return err
+	}
+
+	// Permanently rejected batch is committed as sent to avoid repeating it forever.
+	n.commit(now, prev, notifications)
+	n.pending = append([]Notification(nil), overflow...)
+
+	return err
+}
+
+// commit updates state after notifications were sent.
+func (n *Notifier) commit(now time.Time, prev map[*logz.Observer]map[string]uint64, sent []Notification) {
+	n.prevTime = now
+	n.prev = prev
+
+	for _, nt := range sent {
+		n.notified[nt.key()] = now
+	}
+
+	// Expired records are removed to keep memory bounded.
+	for k, t := range n.notified {
+		if now.Sub(t) >= n.cfg.DedupWindow {
+			delete(n.notified, k)
+		}
+	}
+}
+
+func (nt Notification) key() string {
+	return nt.Kind + "\x00" + nt.Group + "\x00" + nt.Level + "\x00" + nt.Message
+}
+
+func (n *Notifier) groups() []logz.Group {
+	if n.cfg.Registry != nil {
+		return n.cfg.Registry.Groups()
+	}
+
+	return []logz.Group{{Observers: n.cfg.Observers}}
+}
+
+func (n *Notifier) watched(o *logz.Observer) bool {
+	if o.Disabled {
+		return false
+	}
+
+	for _, l := range n.cfg.Levels {
+		if strings.EqualFold(l, o.Name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// collect finds notifications and counts of families, pending notifications go first.
+func (n *Notifier) collect(now time.Time) ([]Notification, map[*logz.Observer]map[string]uint64) {
+	minutes := now.Sub(n.prevTime).Minutes()
+	prevCounts := make(map[*logz.Observer]map[string]uint64, len(n.prev))
+
+	res := append([]Notification(nil), n.pending...)
+	queued := make(map[string]bool, len(res))
+
+	for _, nt := range res {
+		queued[nt.key()] = true
+	}
+
+	for _, g := range n.groups() {
+		for _, o := range g.Observers {
+			if !n.watched(o) {
+				continue
+			}
+
+			prev := n.prev[o]
+			cur := make(map[string]uint64, len(prev))
+
+			for _, e := range o.GetEntries() {
+				cur[e.Message] = e.Count
+
+				p, seen := prev[e.Message]
+				nt := Notification{
+					Group:   g.Name,
+					Level:   o.Name,
+					Message: e.Message,
+					Count:   e.Count,
+				}
+
+				if minutes > 0 && e.Count >= p {
+					nt.Rate = float64(e.Count-p) / minutes
+				}
+
+				switch {
+				case !seen:
+					nt.Kind = KindNew
+				case n.cfg.RateThreshold > 0 && nt.Rate > n.cfg.RateThreshold:
+					nt.Kind = KindSpike
+				default:
+					continue
+				}
+
+				if queued[nt.key()] || n.deduped(nt, now) {
+					continue
+				}
+
+				if samples := o.Find(e.Message).Samples; len(samples) > 0 {
+					nt.Sample = &samples[len(samples)-1]
+				}
+
+				res = append(res, nt)
+			}
+
+			prevCounts[o] = cur
+		}
+	}
+
+	return res, prevCounts
+}
+
+// deduped tells whether same notification was sent recently.
+func (n *Notifier) deduped(nt Notification, now time.Time) bool {
+	last, ok := n.notified[nt.key()]
+
+	return ok && now.Sub(last) < n.cfg.DedupWindow
+}
+
+// errPermanent marks failures that are not retried.
+var errPermanent = errors.New("permanent failure")
+
+func (n *Notifier) send(ctx context.Context, p Payload) error {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	backoff := n.cfg.Backoff
+
+	for attempt := 0; ; attempt++ {
+		err = n.post(ctx, body)
+		if err == nil || errors.Is(err, errPermanent) || attempt >= n.cfg.MaxRetries {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		backoff *= 2
+	}
+}
+
+func (n *Notifier) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: %v", errPermanent, err) //nolint:errorlint // Request error is not inspected.
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.cfg.Client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body) //nolint:errcheck // Draining for connection reuse.
+		_ = resp.Body.Close()                 //nolint:errcheck // Nothing to do with error.
+	}()
+
+	switch {
+	case resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+		return fmt.Errorf("unexpected response status %s", resp.Status)
+	default:
+		return fmt.Errorf("%w: unexpected response status %s", errPermanent, resp.Status)
+	}
+}
@@ -0,0 +1,182 @@
+package logznotify_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bool64/logz"
+	"github.com/bool64/logz/logznotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier_Check(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		payloads []logznotify.Payload
+		requests int
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		requests++
+
+		// First request fails to check retry.
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		var p logznotify.Payload
+
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&p))
+
+		payloads = append(payloads, p)
+	}))
+	defer srv.Close()
+
+	var r logz.Registry
+
+	errs := &logz.Observer{Config: logz.Config{Name: "Error"}}
+	info := &logz.Observer{Config: logz.Config{Name: "Info"}}
+	r.Add("app", info, errs)
+
+	n := logznotify.New(logznotify.Config{
+		URL:           srv.URL,
+		Registry:      &r,
+		RateThreshold: 1000,
+		Backoff:       time.Millisecond,
+	})
+
+	ctx := context.Background()
+
+	info.ObserveMessage("started", nil)
+	errs.ObserveMessage("failed", 123)
+
+	require.NoError(t, n.Check(ctx))
+	require.Len(t, payloads, 1)
+	assert.Equal(t, 2, requests)
+
+	nt := payloads[0].Notifications
+	require.Len(t, nt, 1)
+	assert.Equal(t, logznotify.KindNew, nt[0].Kind)
+	assert.Equal(t, "app", nt[0].Group)
+	assert.Equal(t, "Error", nt[0].Level)
+	assert.Equal(t, "failed", nt[0].Message)
+	assert.Equal(t, uint64(1), nt[0].Count)
+	assert.Equal(t, 123.0, nt[0].Sample.Data)
+
+	// Rate of events above threshold is a spike.
+	for i := 0; i < 100; i++ {
+		errs.ObserveMessage("failed", i)
+	}
+
+	require.NoError(t, n.Check(ctx))
+	require.Len(t, payloads, 2)
+
+	nt = payloads[1].Notifications
+	require.Len(t, nt, 1)
+	assert.Equal(t, logznotify.KindSpike, nt[0].Kind)
+	assert.Equal(t, uint64(101), nt[0].Count)
+	assert.Greater(t, nt[0].Rate, 1000.0)
+
+	// Repeated spike is deduplicated.
+	for i := 0; i < 100; i++ {
+		errs.ObserveMessage("failed", i)
+	}
+
+	require.NoError(t, n.Check(ctx))
+	assert.Len(t, payloads, 2)
+}
+
+func TestNotifier_Check_permanentError(t *testing.T) {
+	requests := 0
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	o := &logz.Observer{Config: logz.Config{Name: "Warning"}}
+	n := logznotify.New(logznotify.Config{URL: srv.URL, Observers: []*logz.Observer{o}})
+
+	o.ObserveMessage("slow", nil)
+
+	err := n.Check(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "400 Bad Request")
+	assert.Equal(t, 1, requests)
+
+	// Rejected notification is not repeated.
+	require.NoError(t, n.Check(context.Background()))
+	assert.Equal(t, 1, requests)
+}
+
+func TestNotifier_Stop(t *testing.T) {
+	n := logznotify.New(logznotify.Config{URL: "http://localhost"})
+	n.Stop()
+
+	n = logznotify.Start(logznotify.Config{URL: "http://localhost"})
+	n.Stop()
+	n.Stop()
+}
+
+func TestNotifier_Check_outage(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		failing  = true
+		messages []string
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		var p logznotify.Payload
+
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&p))
+
+		for _, nt := range p.Notifications {
+			messages = append(messages, nt.Message)
+		}
+	}))
+	defer srv.Close()
+
+	o := &logz.Observer{Config: logz.Config{Name: "Error"}}
+	o.ObserveMessage("before start", nil)
+
+	n := logznotify.New(logznotify.Config{URL: srv.URL, Observers: []*logz.Observer{o}, MaxBatch: 1, MaxRetries: -1})
+	ctx := context.Background()
+
+	o.ObserveMessage("during outage 1", nil)
+	o.ObserveMessage("during outage 2", nil)
+
+	require.Error(t, n.Check(ctx))
+
+	mu.Lock()
+	failing = false
+	mu.Unlock()
+
+	// Families are not lost after failure, overflow of MaxBatch is sent in next check.
+	require.NoError(t, n.Check(ctx))
+	require.NoError(t, n.Check(ctx))
+	require.NoError(t, n.Check(ctx))
+
+	assert.ElementsMatch(t, []string{"during outage 1", "during outage 2"}, messages)
+}
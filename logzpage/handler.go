@@ -8,22 +8,168 @@ import (
 	"html/template"
 	"math"
 	"net/http"
+	"net/url"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/bool64/logz"
 )
 
 type tplData struct {
+	Group   string
+	Groups  []string
 	Level   string
 	Levels  []string
+	Query   string
 	Entries []logz.Entry
-	Details logz.Entry
-	Other   logz.Entry
+
+	Snapshots []string
+	Since     string
+	SinceTime time.Time
+	Changes   []logz.FamilyChange
+
+	Details   logz.Entry
+	Other     logz.Entry
+	AllowMute bool
+
+	Live            bool
+	RefreshInterval time.Duration
+
+	// From and To limit entries to families active in time range, counts are recomputed for the range.
+	From time.Time
+	To   time.Time
+
+	// TZ is a name of time zone to show time in, empty for server time zone.
+	TZ         string
+	ServerZone string
+	location   *time.Location
+}
+
+// jsonPage is served with format=json URL query parameter.
+type jsonPage struct {
+	Level   string       `json:"level"`
+	Levels  []string     `json:"levels"`
+	Entries []logz.Entry `json:"entries"`
+	Other   logz.Entry   `json:"other"`
+	Details logz.Entry   `json:"details"`
+}
+
+// Time formats time in selected time zone.
+func (d tplData) Time(t time.Time) string {
+	if d.location != nil {
+		t = t.In(d.location)
+	}
+
+	return t.Format(time.RFC3339)
+}
+
+// Histogram renders distribution in selected time zone, axis is added if requested.
+func (d tplData) Histogram(buckets []logz.Bucket, axis bool) template.HTML {
+	return template.HTML(Histogram(buckets, func(o *HistogramOptions) { //nolint:gosec // Data is well-formed.
+		o.Location = d.location
+		o.Axis = axis
+	}))
+}
+
+// Link builds page URL preserving current group, level and query, pairs of key and value override parameters.
+func (d tplData) Link(pairs ...string) string {
+	q := url.Values{}
+
+	if d.Groups != nil {
+		q.Set("group", d.Group)
+	}
+
+	if d.Level != "" {
+		q.Set("level", d.Level)
+	}
+
+	if d.Query != "" {
+		q.Set("q", d.Query)
+	}
+
+	if d.Since != "" {
+		q.Set("since", d.Since)
+	}
+
+	if d.Live {
+		q.Set("live", "1")
+	}
+
+	if !d.From.IsZero() {
+		q.Set("from", d.From.Format(time.RFC3339Nano))
+	}
+
+	if !d.To.IsZero() {
+		q.Set("to", d.To.Format(time.RFC3339Nano))
+	}
+
+	if d.TZ != "" {
+		q.Set("tz", d.TZ)
+	}
+
+	for i := 1; i < len(pairs); i += 2 {
+		k, v := pairs[i-1], pairs[i]
+
+		if v == "" && k != "group" {
+			q.Del(k)
+		} else {
+			q.Set(k, v)
+		}
+	}
+
+	return "?" + q.Encode()
+}
+
+// Config defines handler configuration.
+type Config struct {
+	// Observers are level observers to expose, they are ignored if Registry is set.
+	Observers []*logz.Observer
+
+	// Registry provides named groups of level observers, group selector is rendered if it is set.
+	// Snapshots taken with Registry.Snapshot are available for comparison with current state.
+	Registry *logz.Registry
+
+	// TraceURL is a template of link to tracing UI for samples with trace IDs,
+	// {trace_id} and {span_id} placeholders are replaced with sample values,
+	// for example "https://jaeger.example.com/trace/{trace_id}?uiFind={span_id}".
+	TraceURL string
+
+	// AllowMute enables muting of message families from the page.
+	AllowMute bool
+
+	// RefreshInterval is a period of table updates in live mode.
+	// Default 5 seconds.
+	RefreshInterval time.Duration
 }
 
 // Handler creates HTTP handler to expose entries from observers.
-func Handler(observers ...*logz.Observer) http.Handler { //nolint:funlen // This template is lengthy.
+//
+// Page data is served as JSON with format=json URL query parameter.
+// If Config.AllowMute is set, message family can be muted with same-origin POST request
+// with msg URL query parameter and mute form value of duration, one of "5m", "15m", "1h", "4h",
+// "0" unmutes family.
+//
+// Distribution of a family selected with msg or other=1 URL query parameters is served as SVG image
+// with format=svg or format=sparkline, image size can be set with width and height parameters.
+//
+// Entries can be limited to families active in a time range with from and to URL query parameters
+// in RFC3339 format, counts are recomputed for histogram buckets that overlap the range.
+// Range is selected by dragging over histogram bars. Time is shown in time zone from tz parameter,
+// for example "UTC" or "Europe/Berlin", server time zone is used by default.
+//
+// Live mode is enabled with live=1 URL query parameter, table of entries is then updated
+// in place with fragment=entries requests and changed rows are highlighted.
+func Handler(observers ...*logz.Observer) http.Handler {
+	return NewHandler(Config{Observers: observers})
+}
+
+// NewHandler creates HTTP handler to expose entries from configured observers.
+func NewHandler(cfg Config) http.Handler { //nolint:funlen // This template is lengthy.
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = 5 * time.Second
+	}
+
 	// language=GoTemplate
 	tpl := `{{- /*gotype: github.com/bool64/logz/logzpage.tplData*/ -}}
 <!DOCTYPE html>
@@ -50,62 +196,167 @@ func Handler(observers ...*logz.Observer) http.Handler { //nolint:funlen // This
 			display: inline-block;
 			outline: 1px solid white;
 		}
+		tr.changed td, tr.changed + tr.hist-row td {
+			background-color: #fff3c4;
+		}
+		.hist i {
+			cursor: crosshair;
+		}
+		.hist i.selected {
+			background: rgb(0,120,231);
+		}
+		.axis {
+			position: relative;
+			height: 1.5em;
+			font-size: 75%;
+			color: #777;
+		}
+		.axis span {
+			position: absolute;
+			top: 0;
+			white-space: nowrap;
+			border-left: 1px solid #ccc;
+			padding-left: 2px;
+		}
+		.axis span.end {
+			border-left: none;
+			border-right: 1px solid #ccc;
+			padding: 0 2px 0 0;
+			transform: translateX(-100%);
+		}
     </style>
 </head>
 <body>
 <div class="pure-g" style="padding:2em"><div class="pure-u-1">
 
+{{ if .Groups }}
+<div class="pure-menu pure-menu-horizontal">
+    <span class="pure-menu-heading">Group</span>
+    <ul class="pure-menu-list">
+{{ range .Groups }}
+        <li class="pure-menu-item{{ if eq . $.Group }} pure-menu-selected{{end}}">
+            <a href="{{ $.Link "group" . "level" "" }}" class="pure-menu-link">{{ if . }}{{ . }}{{ else }}default{{ end }}</a>
+        </li>
+{{ end }}
+    </ul>
+</div>
+{{ end }}
+
 <div class="pure-menu pure-menu-horizontal">
     <ul class="pure-menu-list">
 {{ range .Levels }}
         <li class="pure-menu-item{{ if eq . $.Level }} pure-menu-selected{{end}}">
-            <a href="?level={{ . }}" class="pure-menu-link">{{ . }}</a>
+            <a href="{{ $.Link "level" . }}" class="pure-menu-link">{{ . }}</a>
         </li>
 {{ else }}
 {{ end }}
     </ul>
 </div>
 
-<table class="pure-table pure-table-horizontal">
+<form class="pure-form" method="get" style="margin:1em 0">
+{{ if .Groups }}
+    <input type="hidden" name="group" value="{{ .Group }}">
+{{ end }}
+{{ if .Level }}
+    <input type="hidden" name="level" value="{{ .Level }}">
+{{ end }}
+{{ if .Since }}
+    <input type="hidden" name="since" value="{{ .Since }}">
+{{ end }}
+{{ if .Live }}
+    <input type="hidden" name="live" value="1">
+{{ end }}
+{{ if not .From.IsZero }}
+    <input type="hidden" name="from" value="{{ .From.Format "2006-01-02T15:04:05.999999999Z07:00" }}">
+{{ end }}
+{{ if not .To.IsZero }}
+    <input type="hidden" name="to" value="{{ .To.Format "2006-01-02T15:04:05.999999999Z07:00" }}">
+{{ end }}
+    <input type="search" name="q" value="{{ .Query }}" placeholder="Filter messages">
+    <select name="tz" id="tz" title="Time zone" onchange="this.form.submit()">
+        <option value=""{{ if not .TZ }} selected{{ end }}>Server time ({{ .ServerZone }})</option>
+        <option value="UTC"{{ if eq .TZ "UTC" }} selected{{ end }}>UTC</option>
+{{ if and .TZ (ne .TZ "UTC") }}
+        <option value="{{ .TZ }}" selected>{{ .TZ }}</option>
+{{ end }}
+    </select>
+{{ if .Live }}
+    <a href="{{ .Link "live" "" }}" class="pure-button pure-button-active" title="Table is updated every {{ .RefreshInterval }}">Live</a>
+{{ else }}
+    <a href="{{ .Link "live" "1" }}" class="pure-button" title="Update table in place">Live</a>
+{{ end }}
+</form>
+
+{{ if .Snapshots }}
+<div class="pure-menu pure-menu-horizontal">
+    <span class="pure-menu-heading">Compare with</span>
+    <ul class="pure-menu-list">
+        <li class="pure-menu-item{{ if not $.Since }} pure-menu-selected{{end}}">
+            <a href="{{ $.Link "since" "" }}" class="pure-menu-link">none</a>
+        </li>
+{{ range .Snapshots }}
+        <li class="pure-menu-item{{ if eq . $.Since }} pure-menu-selected{{end}}">
+            <a href="{{ $.Link "since" . }}" class="pure-menu-link">{{ . }}</a>
+        </li>
+{{ end }}
+    </ul>
+</div>
+{{ end }}
+
+{{ if .Since }}
+<h3>Changes since {{ .Since }} at {{ .Time .SinceTime }}</h3>
+<table class="pure-table pure-table-horizontal" style="margin-bottom:2em">
     <thead>
     <tr>
         <th>Message</th>
-        <th>First</th>
-        <th>Last</th>
-        <th>Count</th>
+        <th>Change</th>
+        <th>Count before</th>
+        <th title="Events per minute">Rate before</th>
+        <th>Count after</th>
+        <th title="Events per minute">Rate after</th>
     </tr>
     </thead>
     <tbody>
-{{ range .Entries }}
+{{ range .Changes }}
     <tr>
-        <td><a href="?msg={{ .Message }}&amp;level={{ $.Level }}#samples">{{ .Message }}</a></td>
-        <td>{{ time .First }}</td>
-        <td>{{ time .Last }}</td>
-        <td>{{ .Count }}</td>
+        <td><a href="{{ $.Link "msg" .Message }}#samples">{{ .Message }}</a></td>
+        <td>{{ .Change }}</td>
+        <td>{{ .CountBefore }}</td>
+        <td>{{ printf "%.2f" .RateBefore }}</td>
+        <td>{{ .CountAfter }}</td>
+        <td>{{ printf "%.2f" .RateAfter }}</td>
     </tr>
-	{{ if .Buckets }}
-	<tr>
-		<td colspan="4">{{ histogram .Buckets }}</td>
-	</tr>
-	{{ end }}
 {{ else }}
     <tr>
-        <td colspan="4">no rows</td>
+        <td colspan="6">no changes</td>
     </tr>
 {{ end }}
-{{ if .Other.Count }}
+    </tbody>
+</table>
+{{ end }}
+
+{{ if or (not .From.IsZero) (not .To.IsZero) }}
+<p>
+    Families active from {{ if .From.IsZero }}start{{ else }}{{ .Time .From }}{{ end }}
+    to {{ if .To.IsZero }}now{{ else }}{{ .Time .To }}{{ end }}, counts are for this range.
+    <a href="{{ .Link "from" "" "to" "" }}" class="pure-button">Reset range</a>
+</p>
+{{ end }}
+
+<table class="pure-table pure-table-horizontal">
+    <thead>
     <tr>
-        <td><a href="?other=1&level={{ $.Level }}">Other Messages</a></td>
-        <td></td>
-        <td>{{ time .Other.Last }}</td>
-        <td>{{ .Other.Count }}</td>
+        <th>Message</th>
+        <th>First</th>
+        <th>Last</th>
+        <th>Count</th>
+        <th title="Count in last minute">1m</th>
+        <th title="Count in last 5 minutes">5m</th>
+        <th title="Count in last hour">1h</th>
     </tr>
-	{{ if .Other.Buckets }}
-	<tr>
-		<td colspan="4">{{ histogram .Other.Buckets }}</td>
-	</tr>
-	{{ end }}
-{{ end }}
+    </thead>
+    <tbody id="entries">
+{{ template "entries" . }}
     </tbody>
 </table>
 
@@ -113,11 +364,34 @@ func Handler(observers ...*logz.Observer) http.Handler { //nolint:funlen // This
 
 	{{ if .Details.Message }}
 		<h2>{{ .Details.Message }}</h2>
+		{{ if $.AllowMute }}
+		<form class="pure-form" method="post" action="{{ $.Link "msg" .Details.Message }}" style="margin:1em 0">
+		{{ if not .Details.MutedUntil.IsZero }}
+			Output muted until {{ $.Time .Details.MutedUntil }}
+			<button type="submit" name="mute" value="0" class="pure-button">Unmute</button>
+		{{ else }}
+			<select name="mute">
+				<option value="5m">5 minutes</option>
+				<option value="15m">15 minutes</option>
+				<option value="1h">1 hour</option>
+				<option value="4h">4 hours</option>
+			</select>
+			<button type="submit" class="pure-button" title="Stop writing this message to log output, it is still counted">Mute output</button>
+		{{ end }}
+		</form>
+		{{ end }}
 	{{ else }}
 		<h2>Other Messages</h2>
 	{{ end }}
 
-{{ histogram .Details.Buckets }}
+{{ $.Histogram .Details.Buckets true }}
+{{ if .Details.Message }}
+<p>Images: <a href="{{ $.Link "msg" .Details.Message "live" "" "format" "svg" }}">histogram</a>,
+<a href="{{ $.Link "msg" .Details.Message "live" "" "format" "sparkline" }}">sparkline</a></p>
+{{ else }}
+<p>Images: <a href="{{ $.Link "other" "1" "live" "" "format" "svg" }}">histogram</a>,
+<a href="{{ $.Link "other" "1" "live" "" "format" "sparkline" }}">sparkline</a></p>
+{{ end }}
 
 <h3 id="samples">Samples</h3>
 <table class="pure-table pure-table-horizontal">
@@ -125,14 +399,21 @@ func Handler(observers ...*logz.Observer) http.Handler { //nolint:funlen // This
     <tr>
         <th>When</th>
         <th>Message</th>
+        <th>Trace</th>
         <th>Data</th>
     </tr>
     </thead>
     <tbody>
 {{ range .Details.Samples }}
     <tr>
-        <td>{{ time .Time }}</td>
+        <td>{{ $.Time .Time }}</td>
         <td>{{ .Msg }}</td>
+        <td>
+        {{ if .TraceID }}
+            {{ with traceURL . }}<a href="{{ . }}" target="_blank">{{ end }}<code>{{ .TraceID }}</code>{{ if traceURL . }}</a>{{ end }}
+            {{ if .SpanID }}<br><code>{{ .SpanID }}</code>{{ end }}
+        {{ end }}
+        </td>
         <td><pre><code>{{ marshal .Data }}</code></pre></td>
     </tr>
 {{ end }}
@@ -140,71 +421,532 @@ func Handler(observers ...*logz.Observer) http.Handler { //nolint:funlen // This
 {{ end }}
 
 </div></div>
+<script>
+(function () {
+    var tz = document.getElementById("tz"),
+        browserTZ = window.Intl && Intl.DateTimeFormat().resolvedOptions().timeZone;
+
+    if (tz && browserTZ && !tz.querySelector("option[value='" + browserTZ + "']")) {
+        var opt = document.createElement("option");
+
+        opt.value = browserTZ;
+        opt.text = browserTZ + " (browser)";
+        tz.appendChild(opt);
+    }
+
+    // Dragging over histogram bars selects time range to zoom in.
+    var start = null, last = null;
+
+    function bars(end) {
+        var all = Array.prototype.slice.call(start.parentNode.children),
+            i = all.indexOf(start), j = all.indexOf(end);
+
+        return all.slice(Math.min(i, j), Math.max(i, j) + 1);
+    }
+
+    function clear() {
+        document.querySelectorAll(".hist i.selected").forEach(function (el) {
+            el.classList.remove("selected");
+        });
+    }
+
+    document.addEventListener("mousedown", function (e) {
+        if (e.target.matches(".hist i")) {
+            e.preventDefault();
+            start = last = e.target;
+            start.classList.add("selected");
+        }
+    });
+
+    document.addEventListener("mouseover", function (e) {
+        if (start && e.target.parentNode === start.parentNode) {
+            last = e.target;
+            clear();
+            bars(e.target).forEach(function (el) {
+                el.classList.add("selected");
+            });
+        }
+    });
+
+    document.addEventListener("mouseup", function () {
+        if (!start) {
+            return;
+        }
+
+        var sel = bars(last),
+            url = new URL(location.href);
+
+        start = null;
+
+        url.searchParams.set("from", sel[0].getAttribute("data-from"));
+        url.searchParams.set("to", sel[sel.length - 1].getAttribute("data-to"));
+        url.searchParams.delete("fragment");
+        location.href = url.toString();
+    });
+})();
+</script>
+{{ if .Live }}
+<script>
+(function () {
+    var tbody = document.getElementById("entries"),
+        interval = {{ .RefreshInterval.Milliseconds }},
+        url = new URL(location.href);
+
+    url.searchParams.set("fragment", "entries");
+    url.hash = "";
+
+    function key(tr) {
+        return tr.hasAttribute("data-other") ? "" : "msg:" + tr.getAttribute("data-family");
+    }
+
+    function refresh() {
+        fetch(url).then(function (resp) {
+            if (!resp.ok) {
+                throw new Error(resp.statusText);
+            }
+
+            return resp.text();
+        }).then(function (html) {
+            var counts = {};
+
+            tbody.querySelectorAll("tr[data-count]").forEach(function (tr) {
+                counts[key(tr)] = tr.getAttribute("data-count");
+            });
+
+            tbody.innerHTML = html;
+
+            tbody.querySelectorAll("tr[data-count]").forEach(function (tr) {
+                if (counts[key(tr)] !== tr.getAttribute("data-count")) {
+                    tr.classList.add("changed");
+                }
+            });
+        }).catch(function (err) {
+            console.error("logz refresh failed:", err);
+        }).finally(function () {
+            setTimeout(refresh, interval);
+        });
+    }
+
+    setTimeout(refresh, interval);
+})();
+</script>
+{{ end }}
 </body>
-</html>`
+</html>
+{{ define "entries" }}
+{{ range .Entries }}
+    <tr data-family="{{ .Message }}" data-count="{{ .Count }}">
+        <td><a href="{{ $.Link "msg" .Message }}#samples">{{ .Message }}</a></td>
+        <td>{{ $.Time .First }}</td>
+        <td>{{ $.Time .Last }}</td>
+        <td>{{ .Count }}</td>
+        <td>{{ .CountLastMinute }}</td>
+        <td>{{ .CountLast5Minutes }}</td>
+        <td>{{ .CountLastHour }}</td>
+    </tr>
+	{{ if .Buckets }}
+	<tr class="hist-row">
+		<td colspan="7">{{ $.Histogram .Buckets false }}</td>
+	</tr>
+	{{ end }}
+{{ else }}
+    <tr>
+        <td colspan="7">no rows</td>
+    </tr>
+{{ end }}
+{{ if .Other.Count }}
+    <tr data-other="1" data-count="{{ .Other.Count }}">
+        <td><a href="{{ $.Link "other" "1" }}">Other Messages</a></td>
+        <td></td>
+        <td>{{ $.Time .Other.Last }}</td>
+        <td>{{ .Other.Count }}</td>
+        <td>{{ .Other.CountLastMinute }}</td>
+        <td>{{ .Other.CountLast5Minutes }}</td>
+        <td>{{ .Other.CountLastHour }}</td>
+    </tr>
+	{{ if .Other.Buckets }}
+	<tr class="hist-row">
+		<td colspan="7">{{ $.Histogram .Other.Buckets false }}</td>
+	</tr>
+	{{ end }}
+{{ end }}
+{{ end }}`
 
 	t, err := template.New("Logz").Funcs(template.FuncMap{
 		"marshal": marshal,
-		"histogram": func(buckets []logz.Bucket) template.HTML {
-			return template.HTML(Histogram(buckets)) //nolint:gosec // Data is well-formed.
-		},
-		"time": func(t time.Time) string {
-			return t.Format(time.RFC3339)
+		"traceURL": func(s logz.Sample) string {
+			if cfg.TraceURL == "" || s.TraceID == "" {
+				return ""
+			}
+
+			return strings.NewReplacer(
+				"{trace_id}", url.PathEscape(s.TraceID),
+				"{span_id}", url.PathEscape(s.SpanID),
+			).Replace(cfg.TraceURL)
 		},
 	}).Parse(tpl)
 	if err != nil {
 		panic(err)
 	}
 
-	levels := make([]string, 0, len(observers))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		data := tplData{
+			AllowMute:       cfg.AllowMute,
+			Live:            q.Get("live") != "",
+			RefreshInterval: cfg.RefreshInterval,
+		}
+
+		if err := timeParams(&data, q); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
 
-	for _, level := range observers {
-		if level.Name != "" {
-			levels = append(levels, level.Name)
+			return
 		}
-	}
+		observers := cfg.Observers
 
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		currentObserver := observers[0]
+		if cfg.Registry != nil {
+			groups := cfg.Registry.Groups()
+			data.Group = q.Get("group")
 
-		level := r.URL.Query().Get("level")
-		if level != "" {
-			for _, observer := range observers {
-				if observer.Name == level {
-					currentObserver = observer
+			if !q.Has("group") && len(groups) > 0 {
+				data.Group = groups[0].Name
+			}
+
+			for _, g := range groups {
+				data.Groups = append(data.Groups, g.Name)
+			}
+
+			observers = cfg.Registry.Group(data.Group)
+
+			for _, s := range cfg.Registry.Snapshots() {
+				data.Snapshots = append(data.Snapshots, s.Name)
+			}
+		}
 
-					break
+		currentObserver := levelObserver(observers, q.Get("level"))
+		if currentObserver == nil {
+			data.Level = q.Get("level")
+		} else {
+			data.Level = currentObserver.Name
+		}
+
+		for _, o := range observers {
+			if o.Name != "" && !o.Disabled {
+				data.Levels = append(data.Levels, o.Name)
+			}
+		}
+
+		if r.Method == http.MethodPost {
+			mute(w, r, cfg, currentObserver)
+
+			return
+		}
+
+		if currentObserver != nil {
+			data.Query = q.Get("q")
+			data.Entries = filterEntries(currentObserver.GetEntries(), data.Query)
+
+			sort.Slice(data.Entries, func(i, j int) bool {
+				return data.Entries[i].Message < data.Entries[j].Message
+			})
+
+			data.Other = currentObserver.Other(false)
+
+			if !data.From.IsZero() || !data.To.IsZero() {
+				data.Entries = filterRange(data.Entries, data.From, data.To)
+
+				if o, ok := entryInRange(data.Other, data.From, data.To); ok {
+					data.Other = o
+				} else {
+					data.Other = logz.Entry{}
 				}
 			}
+
+			if since := q.Get("since"); since != "" && cfg.Registry != nil {
+				diff(&data, cfg.Registry, since, currentObserver)
+			}
+
+			if msg := q.Get("msg"); msg != "" {
+				data.Details = currentObserver.Find(msg)
+			} else if q.Get("other") != "" {
+				data.Details = currentObserver.Other(true)
+			}
+
+			if d, ok := entryInRange(data.Details, data.From, data.To); ok {
+				data.Details = d
+			}
 		}
 
-		entries := currentObserver.GetEntries()
+		if f := q.Get("format"); f == "svg" || f == "sparkline" {
+			svg(w, q, f, data.Details)
 
-		sort.Slice(entries, func(i, j int) bool {
-			return entries[i].Message < entries[j].Message
-		})
+			return
+		}
 
-		data := tplData{
-			Levels:  levels,
-			Level:   level,
-			Entries: entries,
-			Other:   currentObserver.Other(false),
+		if q.Get("format") == "json" {
+			w.Header().Set("Content-Type", "application/json")
+
+			enc := json.NewEncoder(w)
+			enc.SetEscapeHTML(false)
+
+			if err := enc.Encode(jsonPage{
+				Level:   data.Level,
+				Levels:  data.Levels,
+				Entries: data.Entries,
+				Other:   data.Other,
+				Details: data.Details,
+			}); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+
+			return
 		}
 
-		msg := r.URL.Query().Get("msg")
-		if msg != "" {
-			data.Details = currentObserver.Find(msg)
-		} else if r.URL.Query().Get("other") != "" {
-			data.Details = currentObserver.Other(true)
+		var err error
+
+		if q.Get("fragment") == "entries" {
+			err = t.ExecuteTemplate(w, "entries", data)
+		} else {
+			err = t.Execute(w, data)
 		}
 
-		err := t.Execute(w, data)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 		}
 	})
 }
 
+// svg serves distribution of message family as SVG image.
+func svg(w http.ResponseWriter, q url.Values, format string, e logz.Entry) {
+	if e.Count == 0 {
+		http.Error(w, "message not found", http.StatusNotFound)
+
+		return
+	}
+
+	title := e.Message
+	if title == "" {
+		title = "Other Messages"
+	}
+
+	var img string
+
+	if format == "sparkline" {
+		width, height := svgSize(q.Get("width"), q.Get("height"), sparklineWidth, sparklineHeight)
+		img = Sparkline(title, e.Buckets, width, height)
+	} else {
+		width, height := svgSize(q.Get("width"), q.Get("height"), histogramWidth, histogramHeight)
+		img = HistogramSVG(title, e.Buckets, width, height)
+	}
+
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	_, _ = w.Write([]byte(img)) //nolint:errcheck // Nothing to do with error.
+}
+
+// muteDurations are allowed values of mute form.
+var muteDurations = map[string]time.Duration{
+	"0":   0,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"1h":  time.Hour,
+	"4h":  4 * time.Hour,
+}
+
+// mute applies mute form and redirects back to the page.
+func mute(w http.ResponseWriter, r *http.Request, cfg Config, o *logz.Observer) {
+	if !cfg.AllowMute {
+		http.Error(w, "muting is disabled", http.StatusForbidden)
+
+		return
+	}
+
+	if !sameOrigin(r) {
+		http.Error(w, "cross-site request rejected", http.StatusForbidden)
+
+		return
+	}
+
+	d, ok := muteDurations[r.PostFormValue("mute")]
+	if !ok {
+		http.Error(w, "invalid mute duration", http.StatusBadRequest)
+
+		return
+	}
+
+	if o == nil || !o.Mute(r.URL.Query().Get("msg"), d) {
+		http.Error(w, "message not found", http.StatusNotFound)
+
+		return
+	}
+
+	http.Redirect(w, r, r.URL.String()+"#samples", http.StatusSeeOther)
+}
+
+// sameOrigin checks that request is not made by a page of another site,
+// requests without Sec-Fetch-Site and Origin headers are made by non-browser clients and are allowed.
+func sameOrigin(r *http.Request) bool {
+	if site := r.Header.Get("Sec-Fetch-Site"); site != "" {
+		return site == "same-origin" || site == "none"
+	}
+
+	if origin := r.Header.Get("Origin"); origin != "" {
+		u, err := url.Parse(origin)
+
+		return err == nil && u.Host == r.Host
+	}
+
+	return true
+}
+
+// diff compares observer with a snapshot taken earlier.
+func diff(data *tplData, r *logz.Registry, since string, o *logz.Observer) {
+	for _, s := range r.Snapshots() {
+		if s.Name != since {
+			continue
+		}
+
+		data.Since = s.Name
+		data.SinceTime = s.Time
+
+		for _, c := range s.Diff(logz.TakeSnapshot("", o), 0) {
+			if strings.Contains(c.Message, data.Query) {
+				data.Changes = append(data.Changes, c)
+			}
+		}
+	}
+}
+
+// timeParams reads time zone and time range from URL query.
+func timeParams(data *tplData, q url.Values) error {
+	data.ServerZone, _ = time.Now().Zone()
+
+	if tz := q.Get("tz"); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return err
+		}
+
+		data.TZ = tz
+		data.location = loc
+	}
+
+	for _, p := range []struct {
+		name string
+		t    *time.Time
+	}{
+		{name: "from", t: &data.From},
+		{name: "to", t: &data.To},
+	} {
+		if v := q.Get(p.name); v != "" {
+			t, err := time.Parse(time.RFC3339Nano, v)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", p.name, err)
+			}
+
+			*p.t = t
+		}
+	}
+
+	return nil
+}
+
+// filterRange keeps entries that were active in time range, counts are recomputed for the range.
+func filterRange(entries []logz.Entry, from, to time.Time) []logz.Entry {
+	res := entries[:0]
+
+	for _, e := range entries {
+		if e, ok := entryInRange(e, from, to); ok {
+			res = append(res, e)
+		}
+	}
+
+	return res
+}
+
+// entryInRange limits entry to buckets that overlap time range, zero bound is open.
+//
+// Entry without buckets is kept as is if its first and last time overlap the range.
+func entryInRange(e logz.Entry, from, to time.Time) (logz.Entry, bool) {
+	overlaps := func(f, t time.Time) bool {
+		return (to.IsZero() || !f.After(to)) && (from.IsZero() || !t.Before(from))
+	}
+
+	if from.IsZero() && to.IsZero() {
+		return e, e.Count > 0
+	}
+
+	if len(e.Buckets) == 0 {
+		return e, e.Count > 0 && overlaps(e.First, e.Last)
+	}
+
+	buckets := make([]logz.Bucket, 0, len(e.Buckets))
+	count := uint64(0)
+
+	for _, b := range e.Buckets {
+		if b.Count > 0 && overlaps(b.From, b.To) {
+			buckets = append(buckets, b)
+			count += b.Count
+		}
+	}
+
+	if count == 0 {
+		return e, false
+	}
+
+	e.Buckets = buckets
+	e.Count = count
+
+	if f := buckets[0].From; e.First.Before(f) {
+		e.First = f
+	}
+
+	if l := buckets[len(buckets)-1].To; e.Last.After(l) {
+		e.Last = l
+	}
+
+	return e, true
+}
+
+// filterEntries keeps entries with messages that contain query.
+func filterEntries(entries []logz.Entry, query string) []logz.Entry {
+	if query == "" {
+		return entries
+	}
+
+	res := entries[:0]
+
+	for _, e := range entries {
+		if strings.Contains(e.Message, query) {
+			res = append(res, e)
+		}
+	}
+
+	return res
+}
+
+// levelObserver finds enabled observer by level name, first enabled observer is returned if level is not found.
+func levelObserver(observers []*logz.Observer, level string) *logz.Observer {
+	var first *logz.Observer
+
+	for _, o := range observers {
+		if o.Disabled {
+			continue
+		}
+
+		if o.Name == level {
+			return o
+		}
+
+		if first == nil {
+			first = o
+		}
+	}
+
+	return first
+}
+
 func marshal(v interface{}) template.JS {
 	if bb, ok := v.([]byte); ok {
 		return template.JS(bb) //nolint:gosec // Data is well-formed.
@@ -223,20 +965,103 @@ func marshal(v interface{}) template.JS {
 	return template.JS(b.Bytes()) //nolint:gosec // Data is well-formed.
 }
 
+// HistogramOptions configures Histogram rendering.
+type HistogramOptions struct {
+	// Location is a time zone of tooltips and axis labels, default is time zone of buckets.
+	Location *time.Location
+
+	// Axis enables time axis with labels below bars.
+	Axis bool
+}
+
 // Histogram renders distribution using HTML elements.
-func Histogram(buckets []logz.Bucket) string {
+//
+// Bars have data-from and data-to attributes with RFC3339 bucket bounds in UTC to allow time range selection.
+func Histogram(buckets []logz.Bucket, options ...func(o *HistogramOptions)) string {
+	o := HistogramOptions{}
+
+	for _, option := range options {
+		option(&o)
+	}
+
+	in := func(t time.Time) time.Time {
+		if o.Location != nil {
+			return t.In(o.Location)
+		}
+
+		return t
+	}
+
 	res := `<div class="hist">`
+	bars, totalWidth := histBars(buckets)
+
+	for _, b := range bars {
+		res += fmt.Sprintf(`<i title="%s to %s, count: %d" data-from="%s" data-to="%s" style="width:%.2f%%;height:%.1f%%"></i>`,
+			in(b.From).Format(time.RFC3339), in(b.To).Format(time.RFC3339), b.Count,
+			b.From.UTC().Format(time.RFC3339Nano), b.To.UTC().Format(time.RFC3339Nano),
+			math.Floor(100*float64(b.width)*100/float64(totalWidth))/100,
+			100*b.height)
+	}
+
+	res += "</div>"
+
+	if o.Axis && len(bars) > 0 {
+		res += histAxis(bars, totalWidth, in)
+	}
+
+	return res
+}
+
+// histAxis renders time labels at bar boundaries.
+func histAxis(bars []histBar, totalWidth time.Duration, in func(t time.Time) time.Time) string {
+	const maxTicks = 5
+
+	last := bars[len(bars)-1]
+	layout := "15:04:05"
+
+	switch span := last.To.Sub(bars[0].From); {
+	case span >= 24*time.Hour:
+		layout = "Jan 2 15:04"
+	case span >= time.Hour:
+		layout = "15:04"
+	}
+
+	res := `<div class="axis">`
+	step := (len(bars) + maxTicks - 1) / maxTicks
+	left := time.Duration(0)
+
+	for i, b := range bars {
+		// Ticks close to the end would overlap with the end label.
+		if i%step == 0 && float64(left) < 0.8*float64(totalWidth) {
+			res += fmt.Sprintf(`<span style="left:%.2f%%">%s</span>`,
+				100*float64(left)/float64(totalWidth), in(b.From).Format(layout))
+		}
+
+		left += b.width
+	}
+
+	res += fmt.Sprintf(`<span class="end" style="left:100%%">%s</span>`, in(last.To).Format(layout+" MST"))
+
+	return res + "</div>"
+}
+
+// histBar is a bucket with its display width and height relative to the highest rate.
+type histBar struct {
+	logz.Bucket
+	width  time.Duration
+	height float64
+}
 
+// histBars prepares buckets for rendering, buckets are at least one second wide.
+func histBars(buckets []logz.Bucket) ([]histBar, time.Duration) {
+	bars := make([]histBar, 0, len(buckets))
 	maxRate := 0.0
 	totalWidth := time.Duration(0)
+	rates := make([]float64, 0, len(buckets))
 
 	for _, b := range buckets {
 		width := b.To.Sub(b.From)
 
-		if width == 0 {
-			width = time.Second
-		}
-
 		if width < time.Second {
 			width = time.Second
 		}
@@ -248,28 +1073,16 @@ func Histogram(buckets []logz.Bucket) string {
 		}
 
 		totalWidth += width
-	}
-
-	for _, b := range buckets {
-		width := b.To.Sub(b.From)
 
-		if width == 0 {
-			width = time.Second
-		}
+		bars = append(bars, histBar{Bucket: b, width: width})
+		rates = append(rates, rate)
+	}
 
-		if width < time.Second {
-			width = time.Second
+	if maxRate > 0 {
+		for i := range bars {
+			bars[i].height = rates[i] / maxRate
 		}
-
-		rate := float64(b.Count) / float64(width)
-
-		heightPercent := 100 * rate / maxRate
-
-		res += fmt.Sprintf(`<i title="%s to %s, count: %d" style="width:%.2f%%;height:%.1f%%"></i>`,
-			b.From.Format(time.RFC3339), b.To.Format(time.RFC3339), b.Count,
-			math.Floor(100*float64(width)*100/float64(totalWidth))/100,
-			heightPercent)
 	}
 
-	return res + "</div>"
+	return bars, totalWidth
 }
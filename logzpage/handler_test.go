@@ -0,0 +1,314 @@
+package logzpage_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bool64/logz"
+	"github.com/bool64/logz/logzpage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHandler_registry(t *testing.T) {
+	var r logz.Registry
+
+	dbErr := &logz.Observer{Config: logz.Config{Name: "Error"}}
+	httpErr := &logz.Observer{Config: logz.Config{Name: "Error"}}
+	httpWarn := &logz.Observer{Config: logz.Config{Name: "Warning"}}
+
+	r.Add("db", dbErr)
+	r.Add("http", httpWarn, httpErr)
+
+	dbErr.ObserveMessage("connection lost", nil)
+	httpErr.ObserveMessage("request failed", nil)
+
+	h := logzpage.NewHandler(logzpage.Config{Registry: &r})
+
+	req := httptest.NewRequest(http.MethodGet, "/?group=http&level=Error", nil)
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+
+	body := rw.Body.String()
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Contains(t, body, `<a href="?group=db" class="pure-menu-link">db</a>`)
+	assert.Contains(t, body, `<a href="?group=http&amp;level=Warning" class="pure-menu-link">Warning</a>`)
+	assert.Contains(t, body, "request failed")
+	assert.NotContains(t, body, "connection lost")
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+
+	assert.Contains(t, rw.Body.String(), "connection lost")
+}
+
+func TestHandler_query(t *testing.T) {
+	o := &logz.Observer{Config: logz.Config{Name: "Error"}}
+
+	o.ObserveMessage("db: connection lost", nil)
+	o.ObserveMessage("http: request failed", nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/?q=db:", nil)
+	rw := httptest.NewRecorder()
+	logzpage.Handler(o).ServeHTTP(rw, req)
+
+	body := rw.Body.String()
+	assert.Contains(t, body, `<a href="?level=Error&amp;msg=db%3A&#43;connection&#43;lost&amp;q=db%3A#samples">db: connection lost</a>`)
+	assert.NotContains(t, body, "request failed")
+}
+
+func TestNewHandler_since(t *testing.T) {
+	var r logz.Registry
+
+	o := &logz.Observer{Config: logz.Config{Name: "Error"}}
+	r.Add("app", o)
+
+	o.ObserveMessage("old message", nil)
+	r.Snapshot("before deploy")
+	o.ObserveMessage("new message", nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/?since=before+deploy", nil)
+	rw := httptest.NewRecorder()
+	logzpage.NewHandler(logzpage.Config{Registry: &r}).ServeHTTP(rw, req)
+
+	body := rw.Body.String()
+	assert.Contains(t, body, "<h3>Changes since before deploy at ")
+	assert.Contains(t, body, `<td>new</td>`)
+	assert.Contains(t, body, `<a href="?group=app&amp;level=Error&amp;msg=new&#43;message&amp;since=before&#43;deploy#samples">new message</a>`)
+}
+
+func TestHandler_mute(t *testing.T) {
+	o := &logz.Observer{Config: logz.Config{Name: "Error"}}
+
+	o.ObserveMessage("connection lost", nil)
+
+	h := logzpage.NewHandler(logzpage.Config{Observers: []*logz.Observer{o}, AllowMute: true})
+
+	post := func(h http.Handler, target, mute string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(url.Values{"mute": {mute}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		for k, v := range header {
+			req.Header[k] = v
+		}
+
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, req)
+
+		return rw
+	}
+
+	rw := post(h, "/?level=Error&msg=connection+lost", "15m", http.Header{"Sec-Fetch-Site": {"same-origin"}})
+
+	assert.Equal(t, http.StatusSeeOther, rw.Code)
+	assert.Equal(t, "/?level=Error&msg=connection+lost#samples", rw.Header().Get("Location"))
+	assert.True(t, o.IsMuted("connection lost"))
+
+	req := httptest.NewRequest(http.MethodGet, "/?level=Error&msg=connection+lost", nil)
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+
+	assert.Contains(t, rw.Body.String(), "Output muted until")
+
+	rw = post(h, "/?level=Error&msg=connection+lost", "0", http.Header{"Origin": {"http://example.com"}})
+
+	assert.Equal(t, http.StatusSeeOther, rw.Code)
+	assert.False(t, o.IsMuted("connection lost"))
+
+	rw = post(h, "/?level=Error&msg=unknown", "1h", nil)
+	assert.Equal(t, http.StatusNotFound, rw.Code)
+
+	rw = post(h, "/?level=Error&msg=connection+lost", "87600h", nil)
+	assert.Equal(t, http.StatusBadRequest, rw.Code)
+
+	rw = post(h, "/?level=Error&msg=connection+lost", "1h", http.Header{"Sec-Fetch-Site": {"cross-site"}})
+	assert.Equal(t, http.StatusForbidden, rw.Code)
+
+	rw = post(h, "/?level=Error&msg=connection+lost", "1h", http.Header{"Origin": {"https://evil.example"}})
+	assert.Equal(t, http.StatusForbidden, rw.Code)
+
+	rw = post(logzpage.Handler(o), "/?level=Error&msg=connection+lost", "1h", nil)
+	assert.Equal(t, http.StatusForbidden, rw.Code)
+	assert.False(t, o.IsMuted("connection lost"))
+
+	req = httptest.NewRequest(http.MethodGet, "/?level=Error&msg=connection+lost", nil)
+	rw = httptest.NewRecorder()
+	logzpage.Handler(o).ServeHTTP(rw, req)
+
+	assert.NotContains(t, rw.Body.String(), "Mute output")
+}
+
+func TestHandler_live(t *testing.T) {
+	o := &logz.Observer{Config: logz.Config{Name: "Error"}}
+
+	o.ObserveMessage("connection lost", nil)
+
+	h := logzpage.NewHandler(logzpage.Config{Observers: []*logz.Observer{o}, RefreshInterval: 2 * time.Second})
+
+	req := httptest.NewRequest(http.MethodGet, "/?live=1", nil)
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+
+	body := rw.Body.String()
+	assert.Contains(t, body, `<tbody id="entries">`)
+	assert.Contains(t, body, `<a href="?level=Error&amp;live=1&amp;msg=connection&#43;lost#samples">`)
+	assert.Contains(t, body, "interval =  2000 ,")
+
+	req = httptest.NewRequest(http.MethodGet, "/?live=1&fragment=entries", nil)
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+
+	body = rw.Body.String()
+	assert.Contains(t, body, `<tr data-family="connection lost" data-count="1">`)
+	assert.NotContains(t, body, "<html")
+	assert.NotContains(t, body, "<script>")
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+
+	assert.NotContains(t, rw.Body.String(), `url.searchParams.set("fragment", "entries")`)
+}
+
+func TestHandler_svg(t *testing.T) {
+	o := &logz.Observer{Config: logz.Config{Name: "Error"}}
+
+	o.ObserveMessage("connection <lost>", nil)
+
+	h := logzpage.Handler(o)
+
+	req := httptest.NewRequest(http.MethodGet, "/?level=Error&msg=connection+%3Clost%3E&format=svg", nil)
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, "image/svg+xml", rw.Header().Get("Content-Type"))
+	assert.True(t, strings.HasPrefix(rw.Body.String(),
+		`<svg xmlns="http://www.w3.org/2000/svg" width="600" height="100" viewBox="0 0 600 100">`+
+			`<title>connection &lt;lost&gt;</title><rect x="0.00" y="0.00" width="600.00" height="100.00"`), rw.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/?level=Error&msg=connection+%3Clost%3E&format=sparkline&width=50&height=10", nil)
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, `<svg xmlns="http://www.w3.org/2000/svg" width="50" height="10" viewBox="0 0 50 10">`+
+		`<title>connection &lt;lost&gt;</title>`+
+		`<polyline fill="none" stroke="rgb(66,184,221)" stroke-width="1.5" points="0,1.00 50,1.00"/></svg>`, rw.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/?level=Error&msg=unknown&format=svg", nil)
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusNotFound, rw.Code)
+}
+
+func TestSparkline(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, `<svg xmlns="http://www.w3.org/2000/svg" width="100" height="22" viewBox="0 0 100 22">`+
+		`<title>test</title>`+
+		`<polyline fill="none" stroke="rgb(66,184,221)" stroke-width="1.5" points="25.00,11.00 75.00,1.00"/></svg>`,
+		logzpage.Sparkline("test", []logz.Bucket{
+			{From: now, To: now.Add(time.Minute), Count: 1},
+			{From: now.Add(time.Minute), To: now.Add(2 * time.Minute), Count: 2},
+		}, 100, 22))
+}
+
+func TestHandler_timeRange(t *testing.T) {
+	o := &logz.Observer{Config: logz.Config{Name: "Error", DistInterval: time.Minute, DistResolution: 60}}
+	now := time.Now()
+
+	o.ObserveMessageAt(now.Add(-50*time.Minute), "connection lost", nil)
+	o.ObserveMessageAt(now.Add(-50*time.Minute), "connection lost", nil)
+	o.ObserveMessageAt(now.Add(-5*time.Minute), "connection lost", nil)
+	o.ObserveMessageAt(now.Add(-5*time.Minute), "request failed", nil)
+
+	h := logzpage.Handler(o)
+
+	entries := func(query url.Values) map[string]uint64 {
+		t.Helper()
+
+		query.Set("format", "json")
+
+		req := httptest.NewRequest(http.MethodGet, "/?"+query.Encode(), nil)
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, req)
+
+		require.Equal(t, http.StatusOK, rw.Code, rw.Body.String())
+
+		var data struct {
+			Entries []logz.Entry `json:"entries"`
+		}
+
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &data))
+		assert.NotContains(t, rw.Body.String(), "RefreshInterval")
+
+		res := make(map[string]uint64)
+		for _, e := range data.Entries {
+			res[e.Message] = e.Count
+		}
+
+		return res
+	}
+
+	assert.Equal(t, map[string]uint64{"connection lost": 3, "request failed": 1}, entries(url.Values{}))
+	assert.Equal(t, map[string]uint64{"connection lost": 1, "request failed": 1},
+		entries(url.Values{"from": {now.Add(-10 * time.Minute).Format(time.RFC3339)}}))
+	assert.Equal(t, map[string]uint64{"connection lost": 2},
+		entries(url.Values{"to": {now.Add(-30 * time.Minute).Format(time.RFC3339)}}))
+
+	req := httptest.NewRequest(http.MethodGet, "/?from=yesterday", nil)
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusBadRequest, rw.Code)
+}
+
+func TestHandler_timezone(t *testing.T) {
+	o := &logz.Observer{Config: logz.Config{Name: "Error"}}
+
+	o.ObserveMessageAt(time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC), "connection lost", nil)
+
+	h := logzpage.Handler(o)
+
+	req := httptest.NewRequest(http.MethodGet, "/?tz=UTC&msg=connection+lost", nil)
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+
+	body := rw.Body.String()
+	assert.Contains(t, body, `<option value="UTC" selected>UTC</option>`)
+	assert.Contains(t, body, `<td>2022-01-01T10:00:00Z</td>`)
+	assert.Contains(t, body, `<a href="?level=Error&amp;msg=connection&#43;lost&amp;tz=UTC#samples">`)
+	assert.Contains(t, body, `<div class="axis"><span style="left:0.00%">10:00:00</span>`)
+
+	req = httptest.NewRequest(http.MethodGet, "/?tz=Nowhere/Unknown", nil)
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusBadRequest, rw.Code)
+}
+
+func TestHistogram(t *testing.T) {
+	now := time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC)
+	loc := time.FixedZone("CET", 3600)
+
+	assert.Equal(t, `<div class="hist">`+
+		`<i title="2022-01-01T11:00:00+01:00 to 2022-01-01T11:30:00+01:00, count: 1" data-from="2022-01-01T10:00:00Z" data-to="2022-01-01T10:30:00Z" style="width:50.00%;height:50.0%"></i>`+
+		`<i title="2022-01-01T11:30:00+01:00 to 2022-01-01T12:00:00+01:00, count: 2" data-from="2022-01-01T10:30:00Z" data-to="2022-01-01T11:00:00Z" style="width:50.00%;height:100.0%"></i>`+
+		`</div><div class="axis"><span style="left:0.00%">11:00</span><span style="left:50.00%">11:30</span>`+
+		`<span class="end" style="left:100%">12:00 CET</span></div>`,
+		logzpage.Histogram([]logz.Bucket{
+			{From: now, To: now.Add(30 * time.Minute), Count: 1},
+			{From: now.Add(30 * time.Minute), To: now.Add(time.Hour), Count: 2},
+		}, func(o *logzpage.HistogramOptions) {
+			o.Location = loc
+			o.Axis = true
+		}))
+}
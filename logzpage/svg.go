@@ -0,0 +1,94 @@
+package logzpage
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bool64/logz"
+)
+
+// Default sizes of SVG images in pixels.
+const (
+	histogramWidth  = 600
+	histogramHeight = 100
+	sparklineWidth  = 120
+	sparklineHeight = 20
+	maxSVGSize      = 4000
+)
+
+// HistogramSVG renders distribution as a standalone SVG image with bars of event rate.
+func HistogramSVG(title string, buckets []logz.Bucket, width, height int) string {
+	bars, totalWidth := histBars(buckets)
+	res := svgOpen(title, width, height)
+
+	x := 0.0
+
+	for _, b := range bars {
+		w := float64(width) * float64(b.width) / float64(totalWidth)
+		h := float64(height) * b.height
+
+		res += fmt.Sprintf(`<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" fill="rgb(66,184,221)" stroke="white" stroke-width="1">`+
+			`<title>%s to %s, count: %d</title></rect>`,
+			x, float64(height)-h, w, h,
+			b.From.Format(time.RFC3339), b.To.Format(time.RFC3339), b.Count)
+
+		x += w
+	}
+
+	return res + "</svg>"
+}
+
+// Sparkline renders distribution as a standalone SVG image with a line of event rate.
+func Sparkline(title string, buckets []logz.Bucket, width, height int) string {
+	bars, totalWidth := histBars(buckets)
+	res := svgOpen(title, width, height)
+
+	if len(bars) == 0 {
+		return res + "</svg>"
+	}
+
+	y := func(b histBar) float64 {
+		return 1 + float64(height-2)*(1-b.height)
+	}
+
+	// Single bucket is drawn as a flat line across the image.
+	if len(bars) == 1 {
+		return res + fmt.Sprintf(`<polyline fill="none" stroke="rgb(66,184,221)" stroke-width="1.5" points="0,%.2f %d,%.2f"/></svg>`,
+			y(bars[0]), width, y(bars[0]))
+	}
+
+	points := make([]string, 0, len(bars))
+	x := 0.0
+
+	for _, b := range bars {
+		w := float64(width) * float64(b.width) / float64(totalWidth)
+		points = append(points, fmt.Sprintf("%.2f,%.2f", x+w/2, y(b)))
+		x += w
+	}
+
+	return res + `<polyline fill="none" stroke="rgb(66,184,221)" stroke-width="1.5" points="` +
+		strings.Join(points, " ") + `"/></svg>`
+}
+
+func svgOpen(title string, width, height int) string {
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+
+		`<title>%s</title>`,
+		width, height, width, height, html.EscapeString(title))
+}
+
+// svgSize reads image size from URL query, default size is used for missing or invalid values.
+func svgSize(width, height string, defaultWidth, defaultHeight int) (int, int) {
+	return parseSize(width, defaultWidth), parseSize(height, defaultHeight)
+}
+
+func parseSize(s string, def int) int {
+	v, err := strconv.Atoi(s)
+	if err != nil || v <= 0 || v > maxSVGSize {
+		return def
+	}
+
+	return v
+}
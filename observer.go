@@ -5,9 +5,6 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
-
-	"github.com/vearutop/dynhist-go"
-	"github.com/vearutop/lograte/filter"
 )
 
 // Config defines observer configuration.
@@ -15,6 +12,12 @@ type Config struct {
 	// Name can be used to identify observer instance in a group, for example a group of log levels.
 	Name string
 
+	// Group is a name of observers group in Registry, for example a subsystem or a named logger.
+	Group string
+
+	// Registry, if set, is used by adapters to register their level observers under Group name.
+	Registry *Registry
+
 	// MaxCardinality limits number of distinct message families being tracked.
 	// All messages that exceed cardinality are grouped together as "other".
 	// Default 100.
@@ -37,12 +40,33 @@ type Config struct {
 	// Default one week (168 hours).
 	DistRetentionPeriod time.Duration
 
+	// DistInterval enables fixed width time buckets instead of adaptive distribution.
+	// Buckets are kept in a ring buffer of DistResolution size, for example 1440 buckets
+	// of 1 minute interval cover last 24 hours. DistRetentionPeriod is ignored in this mode.
+	// Fixed buckets of different message families and instances are aligned in time and can be compared.
+	DistInterval time.Duration
+
 	// FilterMessage can reduce cardinality by filtering dynamic parts of messages.
 	// It uses github.com/vearutop/lograte/filter.Dynamic
 	// See https://pkg.go.dev/github.com/vearutop/lograte/filter#Dynamic.
 	// This option is not needed if you already have messages without dynamic interpolated values.
-	// This option worsen performance, so use it only if you need it.
+	// Family keys of recent raw messages are cached, so repeated messages are filtered at a cost of a map lookup,
+	// yet this option worsens performance for highly dynamic messages, so use it only if you need it.
 	FilterMessage bool
+
+	// Disabled turns observer into no-op, for example to skip a level in adapters.
+	Disabled bool
+
+	// RepeatLimit enables suppression of repeated messages in adapters that support it.
+	// Only RepeatLimit messages of a family are forwarded to wrapped logger within RepeatWindow,
+	// other messages are counted and reported with a single summary after the window ends.
+	// Messages grouped as "other" are not suppressed.
+	// Default 0, suppression disabled.
+	RepeatLimit uint64
+
+	// RepeatWindow is a period of RepeatLimit.
+	// Default 1 minute.
+	RepeatWindow time.Duration
 }
 
 // NewObserver creates PreparedObserver.
@@ -61,9 +85,16 @@ type PreparedObserver struct {
 	maxSamples          uint32
 	distResolution      int
 	distRetentionPeriod int64
+	distInterval        int64
 	entries             sync.Map
 	other               *entry
-	filterMessage       bool
+	familyKeys          *familyKeys
+	disabled            bool
+	repeatLimit         uint64
+	repeatWindow        time.Duration
+	muteMu              sync.Mutex
+	mutes               int32
+	mutesUntil          int64
 }
 
 // Observer keeps track of messages.
@@ -76,46 +107,58 @@ type Observer struct {
 }
 
 type entry struct {
-	msg                 string
-	samples             chan Sample
-	count               uint64
-	first               int64
-	latest              int64
-	distribution        *dynhist.Collector
-	distRetentionPeriod int64
+	msg          string
+	samples      ring
+	count        uint64
+	first        int64
+	latest       int64
+	distribution distribution
+	window       window
+	repeats      repeats
+	mutedUntil   int64
 }
 
 // Sample is a single sample of a message.
 type Sample struct {
-	Msg  string      `json:"msg"`
-	Data interface{} `json:"data"`
-	Time time.Time   `json:"time"`
+	Msg     string      `json:"msg"`
+	Data    interface{} `json:"data"`
+	Time    time.Time   `json:"time"`
+	TraceID string      `json:"trace_id,omitempty"`
+	SpanID  string      `json:"span_id,omitempty"`
+}
+
+// TraceCorrelator is implemented by sample data that can provide trace and span IDs.
+//
+// IDs are requested only when sample is stored, empty values mean IDs are not available.
+type TraceCorrelator interface {
+	TraceIDs() (traceID, spanID string)
 }
 
-func (en *entry) push(now int64, sample Sample) {
+// push counts event and stores sample, sample data is built with dataFunc if it is not nil.
+func (en *entry) push(now int64, sample Sample, dataFunc func() interface{}) {
 	cnt := atomic.AddUint64(&en.count, 1)
 
+	en.window.add(sample.Time)
+
 	if en.distribution != nil {
-		en.distribution.Add(float64(now))
-
-		if en.distRetentionPeriod > 0 {
-			en.distribution.Lock()
-			if int64(en.distribution.Buckets[0].Min) < now-en.distRetentionPeriod {
-				en.distribution.Buckets = append(en.distribution.Buckets[:0:0], en.distribution.Buckets[1:]...)
-			}
-			en.distribution.Unlock()
-		}
+		en.distribution.add(now)
 	}
 
-	if cnt > uint64(cap(en.samples)) && now <= atomic.LoadInt64(&en.latest) {
+	if cnt > uint64(len(en.samples.slots)) && now <= atomic.LoadInt64(&en.latest) {
 		return
 	}
 
 	atomic.StoreInt64(&en.latest, now)
 
-	// Push new Sample.
-	<-en.samples
-	en.samples <- sample
+	if dataFunc != nil {
+		sample.Data = dataFunc()
+	}
+
+	if tc, ok := sample.Data.(TraceCorrelator); ok {
+		sample.TraceID, sample.SpanID = tc.TraceIDs()
+	}
+
+	en.samples.push(sample)
 }
 
 func (l *PreparedObserver) initialize(cfg Config) {
@@ -144,23 +187,38 @@ func (l *PreparedObserver) initialize(cfg Config) {
 		l.distRetentionPeriod = int64(168 * time.Hour)
 	}
 
+	l.distInterval = int64(cfg.DistInterval) / l.samplingInterval
+
 	l.other = &entry{
-		samples: make(chan Sample, l.maxSamples),
+		samples: newRing(l.maxSamples),
 	}
-	for i := uint32(0); i < l.maxSamples; i++ {
-		l.other.samples <- Sample{}
+
+	l.other.distribution = l.newDistribution()
+
+	if cfg.FilterMessage {
+		l.familyKeys = newFamilyKeys(familyKeysSize)
 	}
 
-	if l.distResolution > 0 {
-		l.other.distribution = &dynhist.Collector{
-			BucketsLimit: l.distResolution,
-		}
-		l.other.distRetentionPeriod = l.distRetentionPeriod
+	l.disabled = cfg.Disabled
+
+	l.repeatLimit = cfg.RepeatLimit
+
+	l.repeatWindow = cfg.RepeatWindow
+	if l.repeatWindow <= 0 {
+		l.repeatWindow = time.Minute
 	}
+}
 
-	if cfg.FilterMessage {
-		l.filterMessage = true
+func (l *PreparedObserver) newDistribution() distribution {
+	if l.distResolution <= 0 {
+		return nil
 	}
+
+	if l.distInterval > 0 {
+		return newFixedDist(l.distResolution, l.distInterval)
+	}
+
+	return newAdaptiveDist(l.distResolution, l.distRetentionPeriod)
 }
 
 // ObserveMessage updates aggregated information about message.
@@ -172,9 +230,84 @@ func (l *Observer) ObserveMessage(msg string, data interface{}) {
 	l.PreparedObserver.ObserveMessage(msg, data)
 }
 
+// ObserveMessageAt updates aggregated information about message that happened at particular time.
+func (l *Observer) ObserveMessageAt(tn time.Time, msg string, data interface{}) {
+	l.once.Do(func() {
+		l.initialize(l.Config)
+	})
+
+	l.PreparedObserver.ObserveMessageAt(tn, msg, data)
+}
+
+// ObserveMessageFunc updates aggregated information about message, data is only built if sample is stored.
+func (l *Observer) ObserveMessageFunc(msg string, data func() interface{}) {
+	l.once.Do(func() {
+		l.initialize(l.Config)
+	})
+
+	l.PreparedObserver.ObserveMessageFunc(msg, data)
+}
+
+// ObserveMessageDedup updates aggregated information about message and tells whether message
+// should be forwarded to wrapped logger according to RepeatLimit.
+func (l *Observer) ObserveMessageDedup(msg string, data func() interface{}, repeated func(msg string, count uint64)) bool {
+	l.once.Do(func() {
+		l.initialize(l.Config)
+	})
+
+	return l.PreparedObserver.ObserveMessageDedup(msg, data, repeated)
+}
+
 // ObserveMessage updates aggregated information about message.
 func (l *PreparedObserver) ObserveMessage(msg string, data interface{}) {
+	l.ObserveMessageAt(time.Now(), msg, data)
+}
+
+// ObserveMessageAt updates aggregated information about message that happened at particular time.
+//
+// It can be used to replay messages with their original timestamps, for example from log files.
+func (l *PreparedObserver) ObserveMessageAt(tn time.Time, msg string, data interface{}) {
+	l.observe(tn, msg, data, nil)
+}
+
+// ObserveMessageFunc updates aggregated information about message.
+//
+// Data is only built if sample is stored, most of events are only counted,
+// so this saves allocations of payloads in hot paths.
+func (l *PreparedObserver) ObserveMessageFunc(msg string, data func() interface{}) {
+	l.observe(time.Now(), msg, nil, data)
+}
+
+// ObserveMessageDedup updates aggregated information about message and tells whether message
+// should be forwarded to wrapped logger according to RepeatLimit and Mute.
+//
+// Number of suppressed messages of a family is passed to repeated once the window of RepeatLimit ends,
+// repeated is called in a separate goroutine.
+func (l *PreparedObserver) ObserveMessageDedup(msg string, data func() interface{}, repeated func(msg string, count uint64)) bool {
 	tn := time.Now()
+
+	en := l.observe(tn, msg, nil, data)
+	if en == nil || en == l.other {
+		return true
+	}
+
+	if atomic.LoadInt64(&en.mutedUntil) > tn.UnixNano() {
+		return false
+	}
+
+	if l.repeatLimit == 0 {
+		return true
+	}
+
+	return en.repeats.check(tn, l.repeatLimit, l.repeatWindow, en.msg, repeated)
+}
+
+// observe updates entry of message family and returns it, nil is returned for disabled observer.
+func (l *PreparedObserver) observe(tn time.Time, msg string, data interface{}, dataFunc func() interface{}) *entry {
+	if l.disabled {
+		return nil
+	}
+
 	now := tn.UnixNano() / l.samplingInterval
 	s := Sample{
 		Msg:  msg,
@@ -182,14 +315,15 @@ func (l *PreparedObserver) ObserveMessage(msg string, data interface{}) {
 		Time: tn,
 	}
 
-	if l.filterMessage {
-		msg = string(filter.Dynamic([]byte(msg), 200))
+	if l.familyKeys != nil {
+		msg = l.familyKeys.get(msg)
 	}
 
 	if e, ok := l.entries.Load(msg); ok {
-		e.(*entry).push(now, s)
+		en := e.(*entry) //nolint:errcheck // Only entries are stored.
+		en.push(now, s, dataFunc)
 
-		return
+		return en
 	}
 
 	if atomic.LoadUint32(&l.count) < l.maxCardinality {
@@ -197,26 +331,22 @@ func (l *PreparedObserver) ObserveMessage(msg string, data interface{}) {
 			msg:     msg,
 			first:   now,
 			count:   0,
-			samples: make(chan Sample, l.maxSamples),
+			samples: newRing(l.maxSamples),
 		}
 
-		if l.distResolution > 0 {
-			e.distribution = &dynhist.Collector{
-				BucketsLimit: l.distResolution,
-			}
-			e.distRetentionPeriod = l.distRetentionPeriod
-		}
+		e.distribution = l.newDistribution()
 
-		for i := uint32(0); i < l.maxSamples; i++ {
-			e.samples <- Sample{}
-		}
 		l.entries.Store(msg, &e)
 		atomic.AddUint32(&l.count, 1)
 
-		e.push(now, s)
-	} else {
-		l.other.push(now, s)
+		e.push(now, s, dataFunc)
+
+		return &e
 	}
+
+	l.other.push(now, s, dataFunc)
+
+	return l.other
 }
 
 func (l *PreparedObserver) exportEntry(en *entry, withSamples bool) Entry {
@@ -231,32 +361,27 @@ func (l *PreparedObserver) exportEntry(en *entry, withSamples bool) Entry {
 		Last:    unsampleTime(atomic.LoadInt64(&en.latest) * l.samplingInterval),
 	}
 
-	if en.distribution != nil {
-		e.Buckets = make([]Bucket, 0, l.distResolution)
+	if mu := atomic.LoadInt64(&en.mutedUntil); mu > time.Now().UnixNano() {
+		e.MutedUntil = time.Unix(0, mu)
+	}
+
+	e.CountLastMinute, e.CountLast5Minutes, e.CountLastHour = en.window.counts(time.Now())
 
-		en.distribution.Lock()
+	if en.distribution != nil {
+		buckets := en.distribution.buckets(time.Now().UnixNano() / l.samplingInterval)
+		e.Buckets = make([]Bucket, 0, len(buckets))
 
-		for _, b := range en.distribution.Buckets {
+		for _, b := range buckets {
 			e.Buckets = append(e.Buckets, Bucket{
-				From:  unsampleTime(int64(b.Min) * l.samplingInterval),
-				To:    unsampleTime(int64(b.Max) * l.samplingInterval),
-				Count: uint64(b.Count),
+				From:  unsampleTime(b.from * l.samplingInterval),
+				To:    unsampleTime(b.to * l.samplingInterval),
+				Count: b.count,
 			})
 		}
-		en.distribution.Unlock()
 	}
 
 	if withSamples {
-		e.Samples = make([]Sample, 0, l.maxSamples)
-
-		for i := int(l.maxSamples) - 1; i >= 0; i-- {
-			sample := <-en.samples
-			en.samples <- sample
-
-			if !sample.Time.IsZero() {
-				e.Samples = append(e.Samples, sample)
-			}
-		}
+		e.Samples = en.samples.samples()
 	}
 
 	return e
@@ -270,6 +395,14 @@ type Entry struct {
 	First   time.Time
 	Last    time.Time
 
+	// CountLastMinute, CountLast5Minutes and CountLastHour are numbers of events in rolling time windows.
+	CountLastMinute   uint64
+	CountLast5Minutes uint64
+	CountLastHour     uint64
+
+	// MutedUntil is a time until message family is not forwarded to wrapped logger, zero if not muted.
+	MutedUntil time.Time
+
 	MaxBucketCount int
 	Buckets        []Bucket
 }
@@ -324,6 +457,68 @@ func (l *PreparedObserver) Find(msg string) Entry {
 	return e
 }
 
+// Mute stops forwarding of message family to wrapped logger for a duration, family is still observed.
+//
+// Non-positive duration unmutes family. False is returned if family is not found.
+// Muting is supported by adapters that use ObserveMessageDedup or IsMuted.
+func (l *PreparedObserver) Mute(msg string, d time.Duration) bool {
+	e, ok := l.entries.Load(msg)
+	if !ok {
+		return false
+	}
+
+	until := int64(0)
+	if d > 0 {
+		until = time.Now().Add(d).UnixNano()
+	}
+
+	l.muteMu.Lock()
+	defer l.muteMu.Unlock()
+
+	prev := atomic.SwapInt64(&e.(*entry).mutedUntil, until) //nolint:errcheck // Only entries are stored.
+
+	switch {
+	case prev == 0 && until != 0:
+		atomic.AddInt32(&l.mutes, 1)
+	case prev != 0 && until == 0:
+		atomic.AddInt32(&l.mutes, -1)
+	}
+
+	// Latest expiration of mutes allows HasMuted to turn false once mutes expire without unmuting.
+	switch {
+	case atomic.LoadInt32(&l.mutes) == 0:
+		atomic.StoreInt64(&l.mutesUntil, 0)
+	case until > atomic.LoadInt64(&l.mutesUntil):
+		atomic.StoreInt64(&l.mutesUntil, until)
+	}
+
+	return true
+}
+
+// HasMuted tells whether any family is muted, it allows skipping of IsMuted with costly message.
+func (l *PreparedObserver) HasMuted() bool {
+	return atomic.LoadInt32(&l.mutes) != 0 && atomic.LoadInt64(&l.mutesUntil) > time.Now().UnixNano()
+}
+
+// IsMuted tells whether message should not be forwarded to wrapped logger.
+func (l *PreparedObserver) IsMuted(msg string) bool {
+	// Lookup is skipped if no family is muted.
+	if !l.HasMuted() {
+		return false
+	}
+
+	if l.familyKeys != nil {
+		msg = l.familyKeys.get(msg)
+	}
+
+	e, ok := l.entries.Load(msg)
+	if !ok {
+		return false
+	}
+
+	return atomic.LoadInt64(&e.(*entry).mutedUntil) > time.Now().UnixNano() //nolint:errcheck // Only entries are stored.
+}
+
 // Other returns entry for other events.
 func (l *PreparedObserver) Other(withSamples bool) Entry {
 	return l.exportEntry(l.other, withSamples)
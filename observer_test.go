@@ -5,9 +5,11 @@ import (
 	"strconv"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/bool64/logz"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestObserver_ObserveMessage(t *testing.T) {
@@ -26,11 +28,15 @@ func TestObserver_ObserveMessage(t *testing.T) {
 
 	assert.Equal(t, "test", entries[0].Message)
 	assert.Equal(t, uint64(2), entries[0].Count)
+	assert.Equal(t, uint64(2), entries[0].CountLastMinute)
+	assert.Equal(t, uint64(2), entries[0].CountLast5Minutes)
+	assert.Equal(t, uint64(2), entries[0].CountLastHour)
 	assert.NotEmpty(t, entries[0].First)
 	assert.NotEmpty(t, entries[0].Last)
 
 	assert.Equal(t, "another test", entries[1].Message)
 	assert.Equal(t, uint64(1), entries[1].Count)
+	assert.Equal(t, uint64(1), entries[1].CountLastMinute)
 	assert.NotEmpty(t, entries[1].First)
 	assert.NotEmpty(t, entries[1].Last)
 
@@ -74,6 +80,145 @@ func TestObserver_ObserveMessage_filter(t *testing.T) {
 	assert.NotEmpty(t, entry.Samples)
 }
 
+func TestObserver_ObserveMessage_filterCache(t *testing.T) {
+	o := logz.NewObserver(logz.Config{FilterMessage: true})
+
+	// Unique messages exceed cache size and reset it.
+	for i := 0; i < 20000; i++ {
+		o.ObserveMessage("user "+strconv.Itoa(i)+" logged in", i)
+		o.ObserveMessage("static message", i)
+	}
+
+	assert.Equal(t, uint64(20000), o.Find("user X logged in").Count)
+	assert.Equal(t, uint64(20000), o.Find("static message").Count)
+	assert.Len(t, o.GetEntries(), 2)
+}
+
+func TestObserver_ObserveMessageFunc(t *testing.T) {
+	o := logz.NewObserver(logz.Config{SamplingInterval: time.Hour, MaxSamples: 3})
+	built := 0
+
+	for i := 0; i < 10; i++ {
+		i := i
+
+		o.ObserveMessageFunc("test", func() interface{} {
+			built++
+
+			return i
+		})
+	}
+
+	entry := o.Find("test")
+	assert.Equal(t, uint64(10), entry.Count)
+	assert.Equal(t, 3, built)
+	require.Len(t, entry.Samples, 3)
+	assert.Equal(t, 2, entry.Samples[2].Data)
+}
+
+func TestObserver_ObserveMessageDedup(t *testing.T) {
+	o := logz.Observer{Config: logz.Config{RepeatLimit: 3, RepeatWindow: time.Second}}
+	repeated := make(chan uint64, 1)
+	forwarded := 0
+
+	// Windows are aligned to RepeatWindow, loop starts at the beginning of a window to fit in it.
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+
+	for i := 0; i < 10; i++ {
+		if o.ObserveMessageDedup("test", nil, func(msg string, count uint64) {
+			assert.Equal(t, "test", msg)
+			repeated <- count
+		}) {
+			forwarded++
+		}
+	}
+
+	assert.Equal(t, uint64(10), o.Find("test").Count)
+	assert.Equal(t, 3, forwarded)
+
+	select {
+	case cnt := <-repeated:
+		assert.Equal(t, uint64(7), cnt)
+	case <-time.After(2 * time.Second):
+		assert.Fail(t, "suppressed messages are not reported")
+	}
+}
+
+func TestObserver_ObserveMessage_fixedDist(t *testing.T) {
+	o := logz.NewObserver(logz.Config{
+		DistResolution: 60,
+		DistInterval:   time.Minute,
+	})
+
+	o.ObserveMessage("test", 123)
+	o.ObserveMessage("test", 456)
+
+	entry := o.Find("test")
+	require.Len(t, entry.Buckets, 60)
+
+	last := entry.Buckets[59]
+	assert.Equal(t, uint64(2), last.Count)
+	assert.Equal(t, time.Minute, last.To.Sub(last.From))
+	assert.Equal(t, last.From.Truncate(time.Minute), last.From)
+	assert.True(t, last.From.Before(time.Now()))
+
+	for i, b := range entry.Buckets[:59] {
+		assert.Equal(t, uint64(0), b.Count)
+		assert.Equal(t, entry.Buckets[i+1].From, b.To)
+	}
+}
+
+func TestObserver_ObserveMessageAt_beforeEpoch(t *testing.T) {
+	o := logz.NewObserver(logz.Config{
+		DistResolution: 60,
+		DistInterval:   time.Minute,
+	})
+
+	tn := time.Date(1969, 12, 31, 23, 59, 30, 0, time.UTC)
+
+	assert.NotPanics(t, func() {
+		o.ObserveMessageAt(tn, "boom", nil)
+	})
+
+	entry := o.Find("boom")
+	assert.Equal(t, uint64(1), entry.Count)
+	assert.Equal(t, uint64(0), entry.CountLastHour)
+}
+
+func TestObserver_ObserveMessage_samplesConcurrent(t *testing.T) {
+	o := logz.NewObserver(logz.Config{SamplingInterval: time.Nanosecond, MaxSamples: 5})
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < 1000; i++ {
+				o.ObserveMessage("test", i)
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < 100; i++ {
+				samples := o.Find("test").Samples
+				assert.LessOrEqual(t, len(samples), 5)
+				assert.True(t, sort.SliceIsSorted(samples, func(i, j int) bool {
+					return samples[i].Time.Before(samples[j].Time)
+				}))
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	entry := o.Find("test")
+	assert.Equal(t, uint64(20000), entry.Count)
+	assert.Len(t, entry.Samples, 5)
+}
+
 func BenchmarkObserver_ObserveMessage(b *testing.B) {
 	o := logz.NewObserver(logz.Config{})
 	wg := sync.WaitGroup{}
@@ -119,3 +264,109 @@ func BenchmarkObserver_ObserveMessage_filter(b *testing.B) {
 
 	wg.Wait()
 }
+
+func BenchmarkObserver_ObserveMessage_filterStatic(b *testing.B) {
+	o := logz.NewObserver(logz.Config{FilterMessage: true})
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		o.ObserveMessage("failed to connect to database", i)
+	}
+}
+
+func BenchmarkObserver_ObserveMessage_filterDynamic(b *testing.B) {
+	o := logz.NewObserver(logz.Config{FilterMessage: true})
+	msgs := make([]string, 100)
+
+	for i := range msgs {
+		msgs[i] = "user " + strconv.Itoa(i) + " failed to login from 10.0.0." + strconv.Itoa(i)
+	}
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		o.ObserveMessage(msgs[i%len(msgs)], i)
+	}
+}
+
+func BenchmarkObserver_ObserveMessage_filterUnique(b *testing.B) {
+	o := logz.NewObserver(logz.Config{FilterMessage: true})
+	msgs := make([]string, 100000)
+
+	for i := range msgs {
+		msgs[i] = "user " + strconv.Itoa(i) + " failed to login"
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		o.ObserveMessage(msgs[i%len(msgs)], i)
+	}
+}
+
+func BenchmarkObserver_ObserveMessage_samples(b *testing.B) {
+	for _, concurrency := range []int{1, 8, 64, 512} {
+		concurrency := concurrency
+
+		b.Run(strconv.Itoa(concurrency), func(b *testing.B) {
+			// Every message is sampled to stress sample storage.
+			o := logz.NewObserver(logz.Config{SamplingInterval: time.Nanosecond, DistResolution: -1})
+			wg := sync.WaitGroup{}
+			done := make(chan struct{})
+
+			b.ReportAllocs()
+
+			go func() {
+				for {
+					select {
+					case <-done:
+						return
+					default:
+						o.Find("message")
+					}
+				}
+			}()
+
+			for i := 0; i < concurrency; i++ {
+				wg.Add(1)
+
+				go func() {
+					for i := 0; i < b.N/concurrency; i++ {
+						o.ObserveMessage("message", i)
+					}
+					wg.Done()
+				}()
+			}
+
+			wg.Wait()
+			close(done)
+		})
+	}
+}
+
+func TestObserver_Mute(t *testing.T) {
+	o := logz.Observer{}
+
+	o.ObserveMessage("noisy", nil)
+	assert.False(t, o.HasMuted())
+	assert.False(t, o.IsMuted("noisy"))
+	assert.False(t, o.Mute("unknown", time.Minute))
+
+	assert.True(t, o.Mute("noisy", time.Minute))
+	assert.True(t, o.HasMuted())
+	assert.True(t, o.IsMuted("noisy"))
+	assert.False(t, o.IsMuted("quiet"))
+
+	assert.True(t, o.Mute("noisy", 0))
+	assert.False(t, o.HasMuted())
+	assert.False(t, o.IsMuted("noisy"))
+
+	// Expired mute is not reported without explicit unmute.
+	assert.True(t, o.Mute("noisy", 10*time.Millisecond))
+	assert.True(t, o.HasMuted())
+	time.Sleep(20 * time.Millisecond)
+	assert.False(t, o.HasMuted())
+	assert.False(t, o.IsMuted("noisy"))
+}
@@ -0,0 +1,103 @@
+package logz
+
+import "sync"
+
+// Registry holds named groups of observers.
+//
+// A group usually contains level observers of a subsystem or a named logger.
+// Zero value is ready to use.
+type Registry struct {
+	mu        sync.Mutex
+	groups    []Group
+	snapshots []Snapshot
+}
+
+// Group is a named set of observers.
+type Group struct {
+	Name      string
+	Observers []*Observer
+}
+
+// Add registers observers in a group, group is created if it does not exist.
+func (r *Registry) Add(group string, observers ...*Observer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, g := range r.groups {
+		if g.Name == group {
+			r.groups[i].Observers = append(g.Observers[0:len(g.Observers):len(g.Observers)], observers...)
+
+			return
+		}
+	}
+
+	r.groups = append(r.groups, Group{
+		Name:      group,
+		Observers: append([]*Observer(nil), observers...),
+	})
+}
+
+// Groups returns registered groups in order of registration.
+func (r *Registry) Groups() []Group {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Group(nil), r.groups...)
+}
+
+// Group returns observers of a group or nil if group is not registered.
+func (r *Registry) Group(name string) []*Observer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, g := range r.groups {
+		if g.Name == name {
+			return g.Observers
+		}
+	}
+
+	return nil
+}
+
+// Observers returns observers of all groups.
+func (r *Registry) Observers() []*Observer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var res []*Observer
+
+	for _, g := range r.groups {
+		res = append(res, g.Observers...)
+	}
+
+	return res
+}
+
+// Snapshot takes a snapshot of all registered observers and keeps it by name,
+// previous snapshot with the same name is replaced.
+func (r *Registry) Snapshot(name string) Snapshot {
+	s := TakeSnapshot(name, r.Observers()...)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, prev := range r.snapshots {
+		if prev.Name == name {
+			r.snapshots = append(r.snapshots[:i:i], r.snapshots[i+1:]...)
+
+			break
+		}
+	}
+
+	r.snapshots = append(r.snapshots, s)
+
+	return s
+}
+
+// Snapshots returns kept snapshots in order of creation.
+func (r *Registry) Snapshots() []Snapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Snapshot(nil), r.snapshots...)
+}
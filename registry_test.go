@@ -0,0 +1,30 @@
+package logz_test
+
+import (
+	"testing"
+
+	"github.com/bool64/logz"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_Add(t *testing.T) {
+	var r logz.Registry
+
+	db := &logz.Observer{Config: logz.Config{Name: "Error"}}
+	httpErr := &logz.Observer{Config: logz.Config{Name: "Error"}}
+	httpWarn := &logz.Observer{Config: logz.Config{Name: "Warning"}}
+
+	r.Add("http", httpWarn)
+	r.Add("db", db)
+	r.Add("http", httpErr)
+
+	groups := r.Groups()
+	assert.Len(t, groups, 2)
+	assert.Equal(t, "http", groups[0].Name)
+	assert.Equal(t, []*logz.Observer{httpWarn, httpErr}, groups[0].Observers)
+	assert.Equal(t, "db", groups[1].Name)
+
+	assert.Equal(t, []*logz.Observer{db}, r.Group("db"))
+	assert.Nil(t, r.Group("unknown"))
+	assert.Equal(t, []*logz.Observer{httpWarn, httpErr, db}, r.Observers())
+}
@@ -0,0 +1,54 @@
+package logz
+
+import (
+	"sync"
+	"time"
+)
+
+// repeats tracks suppression of repeated messages of a family.
+type repeats struct {
+	mu         sync.Mutex
+	window     int64
+	count      uint64
+	suppressed uint64
+}
+
+// check counts message in current window and tells whether it is within limit.
+//
+// First suppressed message schedules a report of suppressed messages at the end of window.
+func (r *repeats) check(tn time.Time, limit uint64, window time.Duration, msg string, repeated func(msg string, count uint64)) bool {
+	w := tn.UnixNano() / int64(window)
+
+	r.mu.Lock()
+
+	if w != r.window {
+		r.window = w
+		r.count = 0
+	}
+
+	r.count++
+
+	if r.count <= limit {
+		r.mu.Unlock()
+
+		return true
+	}
+
+	r.suppressed++
+	first := r.suppressed == 1
+
+	r.mu.Unlock()
+
+	if first && repeated != nil {
+		time.AfterFunc(time.Unix(0, (w+1)*int64(window)).Sub(tn), func() {
+			r.mu.Lock()
+			cnt := r.suppressed
+			r.suppressed = 0
+			r.mu.Unlock()
+
+			repeated(msg, cnt)
+		})
+	}
+
+	return false
+}
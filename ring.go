@@ -0,0 +1,78 @@
+package logz
+
+import (
+	"sort"
+	"sync/atomic"
+)
+
+// ring keeps latest samples of a message family.
+//
+// Writers reserve a slot by incrementing position and publish sample with an atomic pointer store,
+// so pushes never wait for other writers or readers. Every published sample carries its sequence
+// number, readers use it to skip slots that were not published yet.
+type ring struct {
+	pos   uint64
+	slots []atomic.Pointer[ringSample]
+}
+
+type ringSample struct {
+	seq uint64
+	Sample
+}
+
+func newRing(size uint32) ring {
+	return ring{
+		slots: make([]atomic.Pointer[ringSample], size),
+	}
+}
+
+func (r *ring) push(s Sample) {
+	if len(r.slots) == 0 {
+		return
+	}
+
+	seq := atomic.AddUint64(&r.pos, 1) - 1
+
+	r.slots[seq%uint64(len(r.slots))].Store(&ringSample{seq: seq, Sample: s})
+}
+
+// samples returns a copy of latest samples ordered by time, oldest first.
+func (r *ring) samples() []Sample {
+	pos := atomic.LoadUint64(&r.pos)
+	size := uint64(len(r.slots))
+
+	from := uint64(0)
+	if pos > size {
+		from = pos - size
+	}
+
+	found := make([]*ringSample, 0, pos-from)
+
+	for i := range r.slots {
+		rs := r.slots[i].Load()
+
+		// Slot is empty or was overwritten by a concurrent writer after position was loaded,
+		// overwritten samples are newer, so result stays consistent with the latest state.
+		if rs == nil || rs.seq < from {
+			continue
+		}
+
+		found = append(found, rs)
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		ti, tj := found[i].Time, found[j].Time
+		if ti.Equal(tj) {
+			return found[i].seq < found[j].seq
+		}
+
+		return ti.Before(tj)
+	})
+
+	res := make([]Sample, 0, len(found))
+	for _, rs := range found {
+		res = append(res, rs.Sample)
+	}
+
+	return res
+}
@@ -0,0 +1,139 @@
+package logz
+
+import (
+	"sort"
+	"time"
+)
+
+// Snapshot contains counts of message families of observers at a point in time.
+type Snapshot struct {
+	Name string
+	Time time.Time
+
+	observers []*Observer
+	families  map[*Observer]map[string]family
+}
+
+type family struct {
+	count uint64
+	first time.Time
+}
+
+// Change describes how message family has changed between snapshots.
+type Change string
+
+// Change values.
+const (
+	ChangeNew      = Change("new")
+	ChangeGone     = Change("gone")
+	ChangeRateUp   = Change("rate up")
+	ChangeRateDown = Change("rate down")
+)
+
+// FamilyChange describes a change of message family between snapshots.
+type FamilyChange struct {
+	Observer *Observer `json:"-"`
+	Message  string
+	Change   Change
+
+	// CountBefore is a number of events before earlier snapshot.
+	CountBefore uint64
+	// CountAfter is a number of events between snapshots.
+	CountAfter uint64
+
+	// RateBefore is a rate of events per minute before earlier snapshot, since first event.
+	RateBefore float64
+	// RateAfter is a rate of events per minute between snapshots.
+	RateAfter float64
+}
+
+// TakeSnapshot captures counts of message families of observers.
+func TakeSnapshot(name string, observers ...*Observer) Snapshot {
+	s := Snapshot{
+		Name:      name,
+		Time:      time.Now(),
+		observers: observers,
+		families:  make(map[*Observer]map[string]family, len(observers)),
+	}
+
+	for _, o := range observers {
+		entries := o.GetEntries()
+		families := make(map[string]family, len(entries))
+
+		for _, e := range entries {
+			families[e.Message] = family{count: e.Count, first: e.First}
+		}
+
+		s.families[o] = families
+	}
+
+	return s
+}
+
+// Diff compares snapshot with a later one and returns new, disappeared and changed message families.
+//
+// Family is considered changed if its rate between snapshots differs from rate before earlier snapshot
+// by at least rateRatio times, default rateRatio is 2. Family is considered gone if it had no events
+// between snapshots while at least one was expected with rate before earlier snapshot.
+func (s Snapshot) Diff(later Snapshot, rateRatio float64) []FamilyChange {
+	if rateRatio <= 1 {
+		rateRatio = 2
+	}
+
+	var (
+		res      []FamilyChange
+		interval = later.Time.Sub(s.Time)
+	)
+
+	for _, o := range later.observers {
+		before := s.families[o]
+
+		for msg, after := range later.families[o] {
+			f := before[msg]
+
+			c := FamilyChange{
+				Observer:    o,
+				Message:     msg,
+				CountBefore: f.count,
+				CountAfter:  after.count - f.count,
+				RateBefore:  perMinute(f.count, s.Time.Sub(f.first)),
+				RateAfter:   perMinute(after.count-f.count, interval),
+			}
+
+			switch {
+			case f.count == 0:
+				c.Change = ChangeNew
+			case c.CountAfter == 0 && c.RateBefore*interval.Minutes() >= 1:
+				c.Change = ChangeGone
+			case c.CountAfter == 0:
+				continue
+			case c.RateAfter >= c.RateBefore*rateRatio:
+				c.Change = ChangeRateUp
+			case c.RateAfter*rateRatio <= c.RateBefore:
+				c.Change = ChangeRateDown
+			default:
+				continue
+			}
+
+			res = append(res, c)
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Observer != res[j].Observer {
+			return res[i].Observer.Name < res[j].Observer.Name
+		}
+
+		return res[i].Message < res[j].Message
+	})
+
+	return res
+}
+
+func perMinute(count uint64, d time.Duration) float64 {
+	if d < time.Second {
+		d = time.Second
+	}
+
+	return float64(count) / d.Minutes()
+}
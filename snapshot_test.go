@@ -0,0 +1,50 @@
+package logz_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bool64/logz"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot_Diff(t *testing.T) {
+	var r logz.Registry
+
+	o := &logz.Observer{Config: logz.Config{Name: "Error"}}
+	r.Add("app", o)
+
+	for i := 0; i < 10; i++ {
+		o.ObserveMessage("steady", i)
+	}
+
+	o.ObserveMessage("gone", nil)
+
+	start := r.Snapshot("start")
+
+	o.ObserveMessage("steady", 10)
+	o.ObserveMessage("new", nil)
+
+	now := logz.TakeSnapshot("now", o)
+	now.Time = start.Time.Add(10 * time.Minute)
+
+	changes := start.Diff(now, 0)
+	require.Len(t, changes, 3)
+
+	assert.Equal(t, "gone", changes[0].Message)
+	assert.Equal(t, logz.ChangeGone, changes[0].Change)
+
+	assert.Equal(t, "new", changes[1].Message)
+	assert.Equal(t, logz.ChangeNew, changes[1].Change)
+	assert.Equal(t, uint64(1), changes[1].CountAfter)
+
+	assert.Equal(t, "steady", changes[2].Message)
+	assert.Equal(t, logz.ChangeRateDown, changes[2].Change)
+	assert.Equal(t, uint64(10), changes[2].CountBefore)
+	assert.Equal(t, uint64(1), changes[2].CountAfter)
+	assert.InDelta(t, 0.1, changes[2].RateAfter, 0.001)
+
+	r.Snapshot("start")
+	assert.Len(t, r.Snapshots(), 1)
+}
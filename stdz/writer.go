@@ -0,0 +1,238 @@
+// Package stdz provides observer for standard library logger and other line-oriented writers.
+package stdz
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/bool64/logz"
+)
+
+// Level names of observers.
+const (
+	LevelDebug   = "Debug"
+	LevelInfo    = "Info"
+	LevelWarning = "Warning"
+	LevelError   = "Error"
+)
+
+// Options configures Writer.
+type Options struct {
+	// DetectLevel enables detection of level by a first word of a message, e.g. "ERROR", "[warn]" or "debug:".
+	// Detected level word is removed from message.
+	DetectLevel bool
+
+	// DefaultLevel is used for messages without detected level, default LevelInfo.
+	DefaultLevel string
+
+	// LevelConfigs are used instead of common config for particular levels.
+	LevelConfigs map[string]logz.Config
+
+	// Prefix is removed from the beginning of lines, see log.Logger.Prefix.
+	Prefix string
+
+	// Flags of log.Logger define header of a line to remove, see log.Flags.
+	Flags int
+
+	// MaxLineLength limits length of a pending line without newline, longer line is observed in parts.
+	// Default 64 KiB.
+	MaxLineLength int
+}
+
+// Writer splits written data into lines and observes them as messages.
+//
+// Written data is passed to underlying writer, if it is not nil.
+type Writer struct {
+	w    io.Writer
+	opts Options
+
+	mu  sync.Mutex
+	buf []byte
+
+	observers []*logz.Observer
+	levels    map[string]*logz.Observer
+}
+
+// NewWriter creates Writer with per-level observers.
+func NewWriter(w io.Writer, cfg logz.Config, options ...func(o *Options)) *Writer {
+	wr := Writer{
+		w:      w,
+		levels: make(map[string]*logz.Observer, 4),
+	}
+
+	for _, option := range options {
+		option(&wr.opts)
+	}
+
+	if wr.opts.DefaultLevel == "" {
+		wr.opts.DefaultLevel = LevelInfo
+	}
+
+	if wr.opts.MaxLineLength <= 0 {
+		wr.opts.MaxLineLength = 64 * 1024
+	}
+
+	for _, name := range []string{LevelDebug, LevelInfo, LevelWarning, LevelError} {
+		c, ok := wr.opts.LevelConfigs[name]
+		if !ok {
+			c = cfg
+		}
+
+		c.Name = name
+		o := &logz.Observer{Config: c}
+
+		wr.observers = append(wr.observers, o)
+		wr.levels[name] = o
+	}
+
+	if cfg.Registry != nil {
+		cfg.Registry.Add(cfg.Group, wr.observers...)
+	}
+
+	return &wr
+}
+
+// Hook sets Writer as an output of logger, previous output of logger is preserved.
+//
+// Prefix and flags of logger are used to remove line headers.
+func Hook(l *log.Logger, cfg logz.Config, options ...func(o *Options)) *Writer {
+	prefix, flags := l.Prefix(), l.Flags()
+
+	w := NewWriter(l.Writer(), cfg, append([]func(o *Options){func(o *Options) {
+		o.Prefix = prefix
+		o.Flags = flags
+	}}, options...)...)
+
+	l.SetOutput(w)
+
+	return w
+}
+
+// LevelObservers returns observers of all levels.
+func (w *Writer) LevelObservers() []*logz.Observer {
+	return w.observers
+}
+
+// Write observes complete lines and passes data to underlying writer.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+
+	w.buf = append(w.buf, p...)
+
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+
+		w.observe(string(w.buf[:i]))
+		w.buf = w.buf[i+1:]
+	}
+
+	// Pending line is flushed when it reaches the limit, so that buffer does not grow without newlines.
+	for len(w.buf) >= w.opts.MaxLineLength {
+		w.observe(string(w.buf[:w.opts.MaxLineLength]))
+		w.buf = w.buf[w.opts.MaxLineLength:]
+	}
+
+	// Reclaim buffer space when all lines are consumed.
+	if len(w.buf) == 0 {
+		w.buf = w.buf[:0:0]
+	}
+
+	w.mu.Unlock()
+
+	if w.w == nil {
+		return len(p), nil
+	}
+
+	return w.w.Write(p)
+}
+
+func (w *Writer) observe(line string) {
+	line = strings.TrimRight(line, "\r")
+	msg := w.cutHeader(line)
+	level := w.opts.DefaultLevel
+
+	if w.opts.DetectLevel {
+		if l, rest := cutLevel(msg); l != "" {
+			level, msg = l, rest
+		}
+	}
+
+	msg = strings.TrimSpace(msg)
+	if msg == "" {
+		return
+	}
+
+	o, ok := w.levels[level]
+	if !ok {
+		o = w.levels[LevelInfo]
+	}
+
+	o.ObserveMessage(msg, line)
+}
+
+// cutHeader removes prefix, date, time and file location written by log.Logger.
+func (w *Writer) cutHeader(line string) string {
+	if w.opts.Flags&log.Lmsgprefix == 0 {
+		line = strings.TrimPrefix(line, w.opts.Prefix)
+	}
+
+	if w.opts.Flags&log.Ldate != 0 {
+		line = cutWord(line, len("2006/01/02"))
+	}
+
+	if w.opts.Flags&(log.Ltime|log.Lmicroseconds) != 0 {
+		n := len("15:04:05")
+		if w.opts.Flags&log.Lmicroseconds != 0 {
+			n = len("15:04:05.000000")
+		}
+
+		line = cutWord(line, n)
+	}
+
+	if w.opts.Flags&(log.Lshortfile|log.Llongfile) != 0 {
+		if i := strings.Index(line, ": "); i >= 0 {
+			line = line[i+2:]
+		}
+	}
+
+	if w.opts.Flags&log.Lmsgprefix != 0 {
+		line = strings.TrimPrefix(line, w.opts.Prefix)
+	}
+
+	return line
+}
+
+// cutWord removes n bytes and a following space.
+func cutWord(s string, n int) string {
+	if len(s) <= n {
+		return ""
+	}
+
+	return strings.TrimPrefix(s[n:], " ")
+}
+
+// cutLevel detects level by the first word of a message.
+func cutLevel(msg string) (level, rest string) {
+	word, rest, _ := strings.Cut(msg, " ")
+
+	switch strings.ToLower(strings.Trim(word, "[]:")) {
+	case "debug", "dbg", "trace":
+		level = LevelDebug
+	case "info", "inf", "notice":
+		level = LevelInfo
+	case "warn", "warning", "wrn":
+		level = LevelWarning
+	case "error", "err", "fatal", "panic", "crit", "critical":
+		level = LevelError
+	default:
+		return "", msg
+	}
+
+	return level, rest
+}
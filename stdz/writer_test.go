@@ -0,0 +1,72 @@
+package stdz_test
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"github.com/bool64/logz"
+	"github.com/bool64/logz/stdz"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHook(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	l := log.New(out, "app: ", log.LstdFlags|log.Lshortfile)
+
+	w := stdz.Hook(l, logz.Config{}, func(o *stdz.Options) {
+		o.DetectLevel = true
+	})
+
+	l.Println("[warn] disk is almost full")
+	l.Printf("ERROR: failed to connect")
+	l.Print("started")
+
+	assert.Contains(t, out.String(), "writer_test.go:")
+	assert.Contains(t, out.String(), ": [warn] disk is almost full\n")
+
+	levels := w.LevelObservers()
+	assert.Equal(t, uint64(1), levels[1].Find("started").Count)
+	assert.Equal(t, uint64(1), levels[2].Find("disk is almost full").Count)
+
+	e := levels[3].Find("failed to connect")
+	assert.Equal(t, uint64(1), e.Count)
+	assert.Contains(t, e.Samples[0].Data, "app: ")
+}
+
+func TestNewWriter(t *testing.T) {
+	w := stdz.NewWriter(nil, logz.Config{})
+
+	_, err := w.Write([]byte("first line\nsecond "))
+	assert.NoError(t, err)
+
+	_, err = w.Write([]byte("line\n\n"))
+	assert.NoError(t, err)
+
+	info := w.LevelObservers()[1]
+	assert.Equal(t, uint64(1), info.Find("first line").Count)
+	assert.Equal(t, uint64(1), info.Find("second line").Count)
+	assert.Len(t, info.GetEntries(), 2)
+}
+
+func TestNewWriter_maxLineLength(t *testing.T) {
+	w := stdz.NewWriter(nil, logz.Config{}, func(o *stdz.Options) {
+		o.MaxLineLength = 10
+	})
+
+	_, err := w.Write([]byte("aaaaabbbbb"))
+	assert.NoError(t, err)
+
+	_, err = w.Write([]byte("cccccdddddeee"))
+	assert.NoError(t, err)
+
+	info := w.LevelObservers()[1]
+	assert.Equal(t, uint64(1), info.Find("aaaaabbbbb").Count)
+	assert.Equal(t, uint64(1), info.Find("cccccddddd").Count)
+	assert.Len(t, info.GetEntries(), 2)
+
+	_, err = w.Write([]byte("\n"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, uint64(1), info.Find("eee").Count)
+}
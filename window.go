@@ -0,0 +1,92 @@
+package logz
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// window counts events in rolling time windows of last minute, last 5 minutes and last hour.
+//
+// Each window is a ring of slots, count for a window is a sum of slots that belong to it,
+// so precision is limited by slot width: 1 second for minute, 10 seconds for 5 minutes and 1 minute for hour.
+type window struct {
+	mu      sync.Mutex
+	minute  [60]slot
+	minutes [30]slot
+	hour    [60]slot
+}
+
+// slot counts events of a period, counting is lock-free unless slot rolls over to a new period.
+type slot struct {
+	at    atomic.Int64
+	count atomic.Uint64
+}
+
+// add counts event of period at, mu serializes reset of slot to a new period.
+//
+// Events of a period that is older than the current period of slot are dropped.
+func (s *slot) add(at int64, mu *sync.Mutex) {
+	if s.at.Load() != at {
+		mu.Lock()
+
+		cur := s.at.Load()
+		if cur > at {
+			mu.Unlock()
+
+			return
+		}
+
+		if cur != at {
+			s.count.Store(0)
+			s.at.Store(at)
+		}
+
+		mu.Unlock()
+	}
+
+	s.count.Add(1)
+}
+
+// get returns count of period at.
+func (s *slot) get(at int64) uint64 {
+	if s.at.Load() != at {
+		return 0
+	}
+
+	return s.count.Load()
+}
+
+func sumSlots(slots []slot, at int64) uint64 {
+	cnt := uint64(0)
+	from := at - int64(len(slots))
+
+	for i := range slots {
+		s := &slots[i]
+
+		if sat := s.at.Load(); sat > from && sat <= at {
+			cnt += s.count.Load()
+		}
+	}
+
+	return cnt
+}
+
+// add counts event at tn, events before the Unix epoch are not counted.
+func (w *window) add(tn time.Time) {
+	sec := tn.Unix()
+	if sec < 0 {
+		return
+	}
+
+	w.minute[sec%60].add(sec, &w.mu)
+	w.minutes[(sec/10)%30].add(sec/10, &w.mu)
+	w.hour[(sec/60)%60].add(sec/60, &w.mu)
+}
+
+// counts returns number of events in last minute, last 5 minutes and last hour relative to tn.
+func (w *window) counts(tn time.Time) (lastMinute, last5Minutes, lastHour uint64) {
+	sec := tn.Unix()
+
+	return sumSlots(w.minute[:], sec), sumSlots(w.minutes[:], sec/10), sumSlots(w.hour[:], sec/60)
+}
@@ -2,15 +2,43 @@
 package zzap
 
 import (
+	"fmt"
+	"io"
+	"time"
+
 	"github.com/bool64/logz"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
+// Options configures observer core.
+type Options struct {
+	// LevelEnabler controls which levels are observed independently of levels written by the wrapped core.
+	// For example, zapcore.DebugLevel enables counting debug messages even if output only writes Info and above,
+	// and zapcore.InfoLevel disables observing debug messages.
+	// By default, levels written by the wrapped core are observed.
+	LevelEnabler zapcore.LevelEnabler
+
+	// LevelConfigs are used instead of common config for particular levels,
+	// for example to keep more samples for errors or to disable observing debug messages.
+	LevelConfigs map[zapcore.Level]logz.Config
+
+	// CrashDump receives JSON dump of observers with samples when DPanic, Panic or Fatal entry is observed,
+	// before process exits, see logz.DumpFile. All groups of Registry are dumped if it is set in config.
+	CrashDump io.Writer
+}
+
 type obCore struct {
-	observers []*logz.Observer
-	encoder   zapcore.Encoder
-	fields    []zapcore.Field
+	observers    []*logz.Observer
+	encoder      zapcore.Encoder
+	fields       []zapcore.Field
+	levelEnabler zapcore.LevelEnabler
+
+	// repeated writes summaries of suppressed messages by level.
+	repeated []func(msg string, count uint64)
+
+	// crashDump writes dump of observers, it is nil if dump is not configured.
+	crashDump func(reason string)
 
 	zapcore.Core
 }
@@ -30,6 +58,37 @@ func (e entry) MarshalJSON() ([]byte, error) {
 	return b.Bytes(), nil
 }
 
+// TraceIDs returns trace and span IDs from well-known fields.
+func (e entry) TraceIDs() (traceID, spanID string) {
+	for _, f := range e.fields {
+		switch f.Key {
+		case "trace_id", "traceID", "traceId", "trace.id":
+			traceID = fieldString(f)
+		case "span_id", "spanID", "spanId", "span.id":
+			spanID = fieldString(f)
+		}
+	}
+
+	return traceID, spanID
+}
+
+func fieldString(f zapcore.Field) string {
+	switch f.Type { //nolint:exhaustive // Other types are not expected for IDs.
+	case zapcore.StringType:
+		return f.String
+	case zapcore.StringerType:
+		if s, ok := f.Interface.(fmt.Stringer); ok {
+			return s.String()
+		}
+	case zapcore.ByteStringType:
+		if b, ok := f.Interface.([]byte); ok {
+			return string(b)
+		}
+	}
+
+	return ""
+}
+
 func (c obCore) With(fields []zapcore.Field) zapcore.Core {
 	if len(fields) == 0 {
 		return c
@@ -41,37 +100,193 @@ func (c obCore) With(fields []zapcore.Field) zapcore.Core {
 	return c
 }
 
+func (c obCore) Enabled(level zapcore.Level) bool {
+	if c.levelEnabler != nil && c.levelEnabler.Enabled(level) {
+		return true
+	}
+
+	return c.Core.Enabled(level)
+}
+
+// Level returns minimum enabled level of wrapped core and observer.
+func (c obCore) Level() zapcore.Level {
+	level := zapcore.LevelOf(c.Core)
+
+	if c.levelEnabler != nil {
+		if l := zapcore.LevelOf(c.levelEnabler); l < level {
+			level = l
+		}
+	}
+
+	return level
+}
+
 func (c obCore) Check(entry zapcore.Entry, checkedEntry *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	o := c.observers[entry.Level+1]
+
+	if (c.levelEnabler != nil && !c.levelEnabler.Enabled(entry.Level)) || o.Disabled {
+		// Observers are dumped before crash even if level is not observed.
+		if c.crashDump != nil && entry.Level >= zapcore.DPanicLevel {
+			checkedEntry = checkedEntry.AddCore(entry, crashCore{obCore: c})
+		}
+
+		return c.Core.Check(entry, checkedEntry)
+	}
+
+	// Muted messages are observed, but not written, family is only built if there are muted families.
+	if o.HasMuted() && o.IsMuted(family(entry)) {
+		return checkedEntry.AddCore(entry, c)
+	}
+
+	if o.RepeatLimit > 0 {
+		// Wrapped core is checked separately, its entry is only written if message is not suppressed.
+		return checkedEntry.AddCore(entry, dedupCore{obCore: c, wrapped: c.Core.Check(entry, nil)})
+	}
+
 	return c.Core.Check(entry, checkedEntry.AddCore(entry, c))
 }
 
 func (c obCore) Write(msg zapcore.Entry, fields []zapcore.Field) error {
-	c.observers[msg.Level+1].ObserveMessage(msg.Message, entry{
-		encoder: c.encoder,
-		msg:     msg,
-		fields:  append(fields, c.fields...),
+	fam := family(msg)
+
+	c.observers[msg.Level+1].ObserveMessageFunc(fam, func() interface{} {
+		return entry{
+			encoder: c.encoder,
+			msg:     msg,
+			fields:  append(fields[0:len(fields):len(fields)], c.fields...),
+		}
 	})
 
+	c.crashed(msg.Level, fam)
+
+	return nil
+}
+
+// crashed dumps observers if entry of message family is going to stop the process.
+func (c obCore) crashed(level zapcore.Level, fam string) {
+	if c.crashDump != nil && level >= zapcore.DPanicLevel {
+		c.crashDump(level.CapitalString() + ": " + fam)
+	}
+}
+
+// family groups messages of named loggers separately, logger name is shown as a prefix.
+func family(msg zapcore.Entry) string {
+	if msg.LoggerName != "" {
+		return msg.LoggerName + ": " + msg.Message
+	}
+
+	return msg.Message
+}
+
+// crashCore dumps observers on entry of a level that is not observed.
+type crashCore struct {
+	obCore
+}
+
+func (c crashCore) Write(msg zapcore.Entry, _ []zapcore.Field) error {
+	c.crashed(msg.Level, family(msg))
+
+	return nil
+}
+
+// dedupCore observes message and writes entry checked by wrapped core if message is not suppressed.
+type dedupCore struct {
+	obCore
+
+	// wrapped is nil if wrapped core does not write entry.
+	wrapped *zapcore.CheckedEntry
+}
+
+func (c dedupCore) Write(msg zapcore.Entry, fields []zapcore.Field) error {
+	fam := family(msg)
+
+	forward := c.observers[msg.Level+1].ObserveMessageDedup(fam, func() interface{} {
+		return entry{
+			encoder: c.encoder,
+			msg:     msg,
+			fields:  append(fields[0:len(fields):len(fields)], c.fields...),
+		}
+	}, c.repeated[msg.Level+1])
+
+	c.crashed(msg.Level, fam)
+
+	if forward && c.wrapped != nil {
+		// Entry is annotated by logger with caller and stack after Check.
+		c.wrapped.Entry = msg
+		c.wrapped.Write(fields...)
+	}
+
 	return nil
 }
 
+// repeatedFunc returns a function to write summary of suppressed messages to core.
+func repeatedFunc(core zapcore.Core, level zapcore.Level) func(msg string, count uint64) {
+	return func(msg string, count uint64) {
+		e := zapcore.Entry{
+			Level:   level,
+			Time:    time.Now(),
+			Message: fmt.Sprintf("message repeated %d times: %s", count, msg),
+		}
+
+		core.Check(e, nil).Write(zap.Uint64("repeated", count))
+	}
+}
+
 // NewOption creates zap option with per-level observers.
-func NewOption(cfg logz.Config) (zap.Option, []*logz.Observer) {
-	var observers []*logz.Observer
+func NewOption(cfg logz.Config, options ...func(o *Options)) (zap.Option, []*logz.Observer) {
+	var (
+		observers []*logz.Observer
+		opts      Options
+	)
+
+	for _, option := range options {
+		option(&opts)
+	}
 
 	for i := zapcore.DebugLevel; i <= zapcore.FatalLevel; i++ {
-		cfg.Name = i.CapitalString()
+		c, ok := opts.LevelConfigs[i]
+		if !ok {
+			c = cfg
+		}
+
+		c.Name = i.CapitalString()
 
 		observers = append(observers, &logz.Observer{
-			Config: cfg,
+			Config: c,
 		})
 	}
 
+	if cfg.Registry != nil {
+		cfg.Registry.Add(cfg.Group, observers...)
+	}
+
+	var crashDump func(reason string)
+
+	if opts.CrashDump != nil {
+		crashDump = func(reason string) {
+			groups := []logz.Group{{Name: cfg.Group, Observers: observers}}
+			if cfg.Registry != nil {
+				groups = cfg.Registry.Groups()
+			}
+
+			_ = logz.WriteDump(opts.CrashDump, reason, groups...) //nolint:errcheck // Best effort.
+		}
+	}
+
 	return zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		repeated := make([]func(msg string, count uint64), 0, len(observers))
+
+		for i := zapcore.DebugLevel; i <= zapcore.FatalLevel; i++ {
+			repeated = append(repeated, repeatedFunc(core, i))
+		}
+
 		return obCore{
-			observers: observers,
-			encoder:   zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
-			Core:      core,
+			observers:    observers,
+			encoder:      zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
+			levelEnabler: opts.LevelEnabler,
+			repeated:     repeated,
+			crashDump:    crashDump,
+			Core:         core,
 		}
 	}), observers
 }
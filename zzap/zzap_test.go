@@ -1,25 +1,35 @@
 package zzap_test
 
 import (
+	"bytes"
 	"encoding/json"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/bool64/logz"
 	"github.com/bool64/logz/zzap"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestNewOption(t *testing.T) {
+	var r logz.Registry
+
 	zc := zap.NewProductionConfig()
 	zz, lo := zzap.NewOption(logz.Config{
 		MaxCardinality: 5,
 		MaxSamples:     10,
+		Group:          "app",
+		Registry:       &r,
 	})
 	zc.OutputPaths = nil
 
+	assert.Equal(t, lo, r.Group("app"))
+
 	l, err := zc.Build(zz)
 	require.NoError(t, err)
 
@@ -31,6 +41,207 @@ func TestNewOption(t *testing.T) {
 	j, err := json.Marshal(entries[0].Samples[0])
 	require.NoError(t, err)
 	assert.Contains(t, string(j), `"msg":"message","index":1,"k":"v"`)
+
+	l.With(zap.String("trace_id", "abc")).Error("failed", zap.String("span_id", "def"))
+
+	entries = lo[zap.ErrorLevel+1].GetEntriesWithSamples()
+	assert.Equal(t, "abc", entries[0].Samples[0].TraceID)
+	assert.Equal(t, "def", entries[0].Samples[0].SpanID)
+}
+
+func TestNewOption_named(t *testing.T) {
+	zc := zap.NewProductionConfig()
+	zz, lo := zzap.NewOption(logz.Config{})
+	zc.OutputPaths = nil
+
+	l, err := zc.Build(zz)
+	require.NoError(t, err)
+
+	l.Named("db").Error("failed")
+	l.Named("http").Error("failed")
+	l.Named("http").Error("failed")
+	l.Error("failed")
+
+	o := lo[zap.ErrorLevel+1]
+	assert.Equal(t, uint64(1), o.Find("db: failed").Count)
+	assert.Equal(t, uint64(2), o.Find("http: failed").Count)
+	assert.Equal(t, uint64(1), o.Find("failed").Count)
+}
+
+func TestNewOption_levelEnabler(t *testing.T) {
+	zc := zap.NewProductionConfig()
+	zc.OutputPaths = nil
+
+	zz, lo := zzap.NewOption(logz.Config{}, func(o *zzap.Options) {
+		o.LevelEnabler = zap.DebugLevel
+	})
+
+	l, err := zc.Build(zz)
+	require.NoError(t, err)
+
+	assert.Equal(t, zap.DebugLevel, l.Level())
+
+	l.Debug("suppressed")
+	l.Info("written")
+
+	assert.Equal(t, uint64(1), lo[zap.DebugLevel+1].Find("suppressed").Count)
+	assert.Equal(t, uint64(1), lo[zap.InfoLevel+1].Find("written").Count)
+
+	zz, lo = zzap.NewOption(logz.Config{}, func(o *zzap.Options) {
+		o.LevelEnabler = zap.WarnLevel
+	})
+
+	l, err = zc.Build(zz)
+	require.NoError(t, err)
+
+	l.Info("written")
+	l.Warn("warning")
+
+	assert.Empty(t, lo[zap.InfoLevel+1].GetEntries())
+	assert.Equal(t, uint64(1), lo[zap.WarnLevel+1].Find("warning").Count)
+}
+
+func TestNewOption_levelConfigs(t *testing.T) {
+	zc := zap.NewDevelopmentConfig()
+	zc.OutputPaths = nil
+
+	zz, lo := zzap.NewOption(logz.Config{MaxCardinality: 5}, func(o *zzap.Options) {
+		o.LevelConfigs = map[zapcore.Level]logz.Config{
+			zap.DebugLevel: {Disabled: true},
+			zap.ErrorLevel: {MaxCardinality: 10},
+		}
+	})
+
+	l, err := zc.Build(zz)
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		l.Debug("debug" + strconv.Itoa(i))
+		l.Info("info" + strconv.Itoa(i))
+		l.Error("error" + strconv.Itoa(i))
+	}
+
+	assert.Equal(t, "DEBUG", lo[zap.DebugLevel+1].Name)
+	assert.Empty(t, lo[zap.DebugLevel+1].GetEntries())
+	assert.Len(t, lo[zap.InfoLevel+1].GetEntries(), 5)
+	assert.Len(t, lo[zap.ErrorLevel+1].GetEntries(), 10)
+}
+
+func TestNewOption_repeatLimit(t *testing.T) {
+	zz, lo := zzap.NewOption(logz.Config{
+		RepeatLimit:  2,
+		RepeatWindow: time.Hour,
+	})
+
+	core, logs := observer.New(zap.InfoLevel)
+	l := zap.New(core, zz, zap.AddCaller(), zap.AddStacktrace(zap.WarnLevel))
+
+	for i := 0; i < 5; i++ {
+		l.Warn("storm", zap.Int("i", i))
+	}
+
+	l.Info("calm")
+	l.Debug("quiet")
+
+	assert.Equal(t, uint64(5), lo[zap.WarnLevel+1].Find("storm").Count)
+	assert.Equal(t, 3, logs.Len())
+	assert.Equal(t, 2, logs.FilterMessage("storm").Len())
+	assert.Equal(t, 1, logs.FilterMessage("calm").Len())
+
+	storm := logs.FilterMessage("storm").All()[0]
+	assert.True(t, storm.Caller.Defined)
+	assert.Contains(t, storm.Caller.File, "zzap_test.go")
+	assert.NotEmpty(t, storm.Stack)
+	assert.Equal(t, int64(0), storm.ContextMap()["i"])
+}
+
+func TestNewOption_repeatLimitTee(t *testing.T) {
+	zz, _ := zzap.NewOption(logz.Config{RepeatLimit: 10, RepeatWindow: time.Hour})
+
+	infoCore, infoLogs := observer.New(zap.InfoLevel)
+	errorCore, errorLogs := observer.New(zap.ErrorLevel)
+	l := zap.New(zapcore.NewTee(infoCore, errorCore), zz)
+
+	l.Info("hello")
+	l.Error("failed")
+
+	assert.Equal(t, 2, infoLogs.Len())
+	assert.Equal(t, 1, errorLogs.Len())
+	assert.Equal(t, 1, errorLogs.FilterMessage("failed").Len())
+}
+
+func TestNewOption_repeatLimitSampler(t *testing.T) {
+	zz, lo := zzap.NewOption(logz.Config{RepeatLimit: 10, RepeatWindow: time.Hour})
+
+	core, logs := observer.New(zap.InfoLevel)
+	l := zap.New(zapcore.NewSamplerWithOptions(core, time.Hour, 2, 0), zz)
+
+	for i := 0; i < 5; i++ {
+		l.Warn("sampled")
+	}
+
+	assert.Equal(t, uint64(5), lo[zap.WarnLevel+1].Find("sampled").Count)
+	assert.Equal(t, 2, logs.Len())
+}
+
+func TestNewOption_mute(t *testing.T) {
+	zz, lo := zzap.NewOption(logz.Config{})
+
+	core, logs := observer.New(zap.InfoLevel)
+	l := zap.New(core, zz).Named("db")
+
+	l.Error("noisy")
+	assert.True(t, lo[zap.ErrorLevel+1].Mute("db: noisy", time.Minute))
+
+	l.Error("noisy")
+	l.Error("quiet")
+
+	assert.Equal(t, uint64(2), lo[zap.ErrorLevel+1].Find("db: noisy").Count)
+	assert.Equal(t, 1, logs.FilterMessage("noisy").Len())
+	assert.Equal(t, 1, logs.FilterMessage("quiet").Len())
+}
+
+func TestNewOption_crashDump(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+
+	zz, _ := zzap.NewOption(logz.Config{Group: "app"}, func(o *zzap.Options) {
+		o.CrashDump = buf
+	})
+
+	core, _ := observer.New(zap.InfoLevel)
+	l := zap.New(core, zz)
+
+	l.Warn("overheating")
+	assert.Empty(t, buf.String())
+
+	assert.Panics(t, func() {
+		l.Panic("meltdown")
+	})
+
+	assert.Contains(t, buf.String(), `"Reason":"PANIC: meltdown"`)
+	assert.Contains(t, buf.String(), `"Message":"overheating"`)
+}
+
+func TestNewOption_crashDumpNotObserved(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+
+	zz, _ := zzap.NewOption(logz.Config{Group: "app"}, func(o *zzap.Options) {
+		o.CrashDump = buf
+		o.LevelConfigs = map[zapcore.Level]logz.Config{zap.PanicLevel: {Disabled: true}}
+	})
+
+	core, logs := observer.New(zap.InfoLevel)
+	l := zap.New(core, zz)
+
+	l.Warn("overheating")
+
+	assert.Panics(t, func() {
+		l.Panic("meltdown")
+	})
+
+	assert.Contains(t, buf.String(), `"Reason":"PANIC: meltdown"`)
+	assert.Contains(t, buf.String(), `"Message":"overheating"`)
+	assert.Equal(t, 1, logs.FilterMessage("meltdown").Len())
 }
 
 func BenchmarkLogzSugarWarn(b *testing.B) {